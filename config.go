@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"e-commerce.com/internal/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// config holds the runtime settings read from the environment.
+type config struct {
+	// QueryTimeout bounds every database query issued while serving a request.
+	QueryTimeout time.Duration
+	// Auth configures verification of the bearer tokens guarding write endpoints.
+	Auth auth.Config
+	// Tokens configures signing of the tokens issued by /auth.
+	Tokens auth.IssuerConfig
+	// PaymentProvider names the payment gateway integration to use.
+	PaymentProvider string
+	// PaymentWebhookSecret verifies the signatures of payment webhooks.
+	PaymentWebhookSecret []byte
+	// IdempotencyKeyTTL is how long responses to requests with an
+	// Idempotency-Key header are kept for replay.
+	IdempotencyKeyTTL time.Duration
+	// TrashRetention is how long deleted products can be restored before
+	// they are purged permanently.
+	TrashRetention time.Duration
+}
+
+// loadConfig reads the configuration from environment variables, falling back
+// to defaults for anything unset or malformed. It only fails when a
+// configured file cannot be used.
+func loadConfig() (config, error) {
+	cfg := config{
+		QueryTimeout: durationFromEnv("DB_QUERY_TIMEOUT", 5*time.Second),
+		Auth: auth.Config{
+			HMACSecret: []byte(os.Getenv("JWT_HS256_SECRET")),
+			Issuer:     os.Getenv("JWT_ISSUER"),
+			Audience:   os.Getenv("JWT_AUDIENCE"),
+		},
+		Tokens: auth.IssuerConfig{
+			HMACSecret:      []byte(os.Getenv("JWT_HS256_SECRET")),
+			Issuer:          os.Getenv("JWT_ISSUER"),
+			Audience:        os.Getenv("JWT_AUDIENCE"),
+			AccessTokenTTL:  durationFromEnv("JWT_ACCESS_TOKEN_TTL", auth.DefaultAccessTokenTTL),
+			RefreshTokenTTL: durationFromEnv("JWT_REFRESH_TOKEN_TTL", auth.DefaultRefreshTokenTTL),
+		},
+		PaymentProvider:      os.Getenv("PAYMENT_PROVIDER"),
+		PaymentWebhookSecret: []byte(os.Getenv("PAYMENT_WEBHOOK_SECRET")),
+		IdempotencyKeyTTL:    durationFromEnv("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+		TrashRetention:       durationFromEnv("PRODUCT_TRASH_RETENTION", 30*24*time.Hour),
+	}
+
+	if cfg.PaymentProvider == "" {
+		cfg.PaymentProvider = "fake"
+	}
+	if cfg.PaymentProvider != "fake" {
+		return cfg, fmt.Errorf("unsupported PAYMENT_PROVIDER %q", cfg.PaymentProvider)
+	}
+
+	if path := os.Getenv("JWT_RS256_PUBLIC_KEY_FILE"); path != "" {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return cfg, fmt.Errorf("reading JWT_RS256_PUBLIC_KEY_FILE: %w", err)
+		}
+		if cfg.Auth.RSAPublicKey, err = jwt.ParseRSAPublicKeyFromPEM(pem); err != nil {
+			return cfg, fmt.Errorf("parsing JWT_RS256_PUBLIC_KEY_FILE: %w", err)
+		}
+	}
+
+	if path := os.Getenv("JWT_RS256_PRIVATE_KEY_FILE"); path != "" {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return cfg, fmt.Errorf("reading JWT_RS256_PRIVATE_KEY_FILE: %w", err)
+		}
+		if cfg.Tokens.RSAPrivateKey, err = jwt.ParseRSAPrivateKeyFromPEM(pem); err != nil {
+			return cfg, fmt.Errorf("parsing JWT_RS256_PRIVATE_KEY_FILE: %w", err)
+		}
+		// Tokens we sign must pass our own verification.
+		if cfg.Auth.RSAPublicKey == nil {
+			cfg.Auth.RSAPublicKey = &cfg.Tokens.RSAPrivateKey.PublicKey
+		}
+	}
+
+	return cfg, nil
+}
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s %q, using %s.", key, value, fallback)
+		return fallback
+	}
+	return d
+}
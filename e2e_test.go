@@ -15,8 +15,11 @@ import (
 	"testing"
 	"time"
 
+	"e-commerce.com/internal/auth"
 	"e-commerce.com/internal/domain"
 
+	"github.com/golang-jwt/jwt/v5"
+
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -25,6 +28,9 @@ import (
 var testDB *sql.DB
 var testServer *httptest.Server
 
+// testJWTSecret signs the tokens the E2E tests use for protected endpoints.
+const testJWTSecret = "e2e-test-secret"
+
 // Special function that manages the lifecycle of tests in this package.
 func TestMain(m *testing.M) {
 	ctx := context.Background()
@@ -61,16 +67,18 @@ func TestMain(m *testing.M) {
 	}
 
 	// Run the migrations
-	createTableSQL := `CREATE TABLE products (
-		id SERIAL PRIMARY KEY, name TEXT NOT NULL, price NUMERIC(10, 2) NOT NULL,
-		amount INTEGER NOT NULL, description TEXT
-	);`
-	if _, err = testDB.Exec(createTableSQL); err != nil {
-		log.Fatalf("could not create table: %s", err)
+	if err = runMigrations(ctx, testDB); err != nil {
+		log.Fatalf("could not run migrations: %s", err)
 	}
 
 	// Start a test server using the router on a random port.
-	router := setupRouter(testDB)
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatalf("could not load config: %s", err)
+	}
+	cfg.Auth = auth.Config{HMACSecret: []byte(testJWTSecret)}
+	cfg.Tokens = auth.IssuerConfig{HMACSecret: []byte(testJWTSecret)}
+	router := setupRouter(testDB, cfg)
 	testServer = httptest.NewServer(router)
 	defer testServer.Close()
 
@@ -79,6 +87,23 @@ func TestMain(m *testing.M) {
 	os.Exit(exitCode)
 }
 
+// testToken returns a short-lived HS256 token granting roles.
+func testToken(t *testing.T, roles ...string) string {
+	t.Helper()
+	claims := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "e2e",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		Roles: roles,
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return token
+}
+
 // Testing the complete life cycle of a product via API.
 func TestE2E_ProductLifecycle(t *testing.T) {
 	var createdProduct domain.Product
@@ -88,7 +113,11 @@ func TestE2E_ProductLifecycle(t *testing.T) {
 		productJSON := `{"name": "E2E Test Mouse", "price": 150.75, "amount": 20, "description": "A mouse for E2E testing"}`
 		body := bytes.NewBufferString(productJSON)
 
-		resp, err := http.Post(testServer.URL+"/products", "application/json", body)
+		req, _ := http.NewRequest(http.MethodPost, testServer.URL+"/products", body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+testToken(t, auth.RoleCatalogManager))
+
+		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
 			t.Fatalf("Failed to create product: %v", err)
 		}
@@ -147,6 +176,7 @@ func TestE2E_ProductLifecycle(t *testing.T) {
 		updatedJSON := `{"name": "Updated E2E Mouse", "price": 160.00, "amount": 15, "description": "An updated mouse"}`
 		req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/products/%d", testServer.URL, createdProduct.ID), bytes.NewBufferString(updatedJSON))
 		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+testToken(t, auth.RoleCatalogManager))
 
 		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
@@ -176,6 +206,7 @@ func TestE2E_ProductLifecycle(t *testing.T) {
 	// DELETE (DELETE /products/{id})
 	t.Run("Delete the product", func(t *testing.T) {
 		req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/products/%d", testServer.URL, createdProduct.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+testToken(t, auth.RoleAdmin))
 		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
 			t.Fatalf("Failed to delete product: %v", err)
@@ -210,3 +241,107 @@ func TestE2E_ProductLifecycle(t *testing.T) {
 		}
 	})
 }
+
+// Write endpoints must reject anonymous callers and callers without a catalog role.
+func TestE2E_WriteRequiresAuthorization(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{"anonymous", "", http.StatusUnauthorized},
+		{"invalid token", "not-a-jwt", http.StatusUnauthorized},
+		{"customer role", testToken(t, auth.RoleCustomer), http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := bytes.NewBufferString(`{"name": "Forbidden", "price": 1, "amount": 1}`)
+			req, _ := http.NewRequest(http.MethodPost, testServer.URL+"/products", body)
+			req.Header.Set("Content-Type", "application/json")
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to send request: %v", err)
+			}
+			defer func(Body io.ReadCloser) {
+				err := Body.Close()
+				if err != nil {
+					t.Errorf("Failed to Close: %v", err)
+				}
+			}(resp.Body)
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+}
+
+// Concurrent checkouts of the same product must never sell more units than are in stock.
+func TestE2E_ConcurrentCheckoutDoesNotOversell(t *testing.T) {
+	const stock, buyers = 5, 20
+
+	var product domain.Product
+	req, _ := http.NewRequest(http.MethodPost, testServer.URL+"/products",
+		bytes.NewBufferString(fmt.Sprintf(`{"name": "Limited Keyboard", "price": 99.90, "amount": %d}`, stock)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+testToken(t, auth.RoleCatalogManager))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to create product: %v", err)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&product); err != nil {
+		t.Fatalf("Failed to decode created product: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	resp, err = http.Post(testServer.URL+"/auth/register", "application/json",
+		bytes.NewBufferString(`{"email": "buyer@example.com", "name": "Buyer", "password": "e2e-password"}`))
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	var tokens struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		t.Fatalf("Failed to decode tokens: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	statuses := make(chan int, buyers)
+	for i := 0; i < buyers; i++ {
+		go func() {
+			body := fmt.Sprintf(`{"items": [{"product_id": %d, "quantity": 1}]}`, product.ID)
+			req, _ := http.NewRequest(http.MethodPost, testServer.URL+"/orders", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				statuses <- 0
+				return
+			}
+			_ = resp.Body.Close()
+			statuses <- resp.StatusCode
+		}()
+	}
+
+	counts := make(map[int]int)
+	for i := 0; i < buyers; i++ {
+		counts[<-statuses]++
+	}
+	if counts[http.StatusCreated] != stock || counts[http.StatusConflict] != buyers-stock {
+		t.Fatalf("Expected %d orders and %d conflicts, got %v", stock, buyers-stock, counts)
+	}
+
+	var left int
+	if err := testDB.QueryRow(`SELECT amount FROM products WHERE id = $1`, product.ID).Scan(&left); err != nil {
+		t.Fatalf("Failed to read stock: %v", err)
+	}
+	if left != 0 {
+		t.Errorf("Expected stock to reach exactly 0, got %d", left)
+	}
+}
@@ -1,5 +1,6 @@
 
 import { useState, useEffect } from 'react';
+import { isAxiosError } from 'axios';
 import type { ChangeEvent, FormEvent } from 'react';
 import type { Product } from './types/Product';
 import * as api from './services/api';
@@ -12,11 +13,16 @@ import './App.css';
 
 const INITIAL_PRODUCT_STATE: Product = {
     name: '',
-    price: 0,
+    price: { amount: '0', currency: 'BRL' },
     amount: 0,
     description: '',
 };
 
+const STALE_MESSAGE = 'Este produto foi alterado por outra pessoa. Atualize a lista e tente novamente.';
+
+// A 412 means the product changed since it was loaded.
+const isStale = (err: unknown) => isAxiosError(err) && err.response?.status === 412;
+
 function App() {
     const [products, setProducts] = useState<Product[]>([]);
     const [currentProduct, setCurrentProduct] = useState<Product>(INITIAL_PRODUCT_STATE);
@@ -57,10 +63,10 @@ function App() {
     const handleDelete = async (id: number) => {
         if (window.confirm('Tem certeza que deseja deletar este produto?')) {
             try {
-                await api.deleteProduct(id);
+                await api.deleteProduct(id, products.find((p) => p.id === id)?.version);
                 refreshProducts();
             } catch (err) {
-                setError('Falha ao deletar produto.');
+                setError(isStale(err) ? STALE_MESSAGE : 'Falha ao deletar produto.');
                 console.error('Erro ao deletar produto:', err);
             }
         }
@@ -86,9 +92,10 @@ function App() {
     };
 
     const handlePriceChange = (value: string | undefined, name?: string) => {
-        const numericValue = value ? parseFloat(value.replace(',', '.')) : 0;
+        // Keep the amount as a decimal string so it reaches the API without float rounding.
+        const amount = value ? value.replace(',', '.') : '0';
         if (name) {
-            setCurrentProduct((prev) => ({ ...prev, [name]: numericValue }));
+            setCurrentProduct((prev) => ({ ...prev, [name]: { ...prev.price, amount } }));
         }
     };
 
@@ -106,7 +113,7 @@ function App() {
             handleCancel();
             refreshProducts();
         } catch (err) {
-            setError('Falha ao salvar produto.');
+            setError(isStale(err) ? STALE_MESSAGE : 'Falha ao salvar produto.');
             console.error('Erro ao salvar produto:', err);
         } finally {
             setIsLoading(false);
@@ -43,7 +43,7 @@ const ProductForm: React.FC<ProductFormProps> = ({
                         id="price"
                         name="price"
                         placeholder="Ex: R$ 15,50"
-                        value={currentProduct.price}
+                        value={currentProduct.price.amount.replace('.', ',')}
                         decimalsLimit={2}
                         decimalSeparator=","
                         groupSeparator="."
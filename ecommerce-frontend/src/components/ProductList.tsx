@@ -41,9 +41,9 @@ const ProductList: React.FC<ProductListProps> = ({
                     <tr key={product.id}>
                         <td>{product.name}</td>
                         <td>
-                            {product.price.toLocaleString('pt-BR', {
+                            {Number(product.price.amount).toLocaleString('pt-BR', {
                                 style: 'currency',
-                                currency: 'BRL',
+                                currency: product.price.currency,
                             })}
                         </td>
                         <td>{product.amount}</td>
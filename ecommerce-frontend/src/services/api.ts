@@ -1,5 +1,6 @@
-import axios from 'axios';
-import type { Product } from '../types/Product';
+import axios, { type AxiosError, type InternalAxiosRequestConfig } from 'axios';
+import type { Product, ProductSuggestion } from '../types/Product';
+import type { TokenResponse } from '../types/User';
 
 const apiClient = axios.create({
     baseURL: 'http://localhost:8080',
@@ -8,16 +9,96 @@ const apiClient = axios.create({
     },
 });
 
+const ACCESS_TOKEN_KEY = 'access_token';
+const REFRESH_TOKEN_KEY = 'refresh_token';
+
+const storeTokens = (tokens: TokenResponse) => {
+    localStorage.setItem(ACCESS_TOKEN_KEY, tokens.access_token);
+    localStorage.setItem(REFRESH_TOKEN_KEY, tokens.refresh_token);
+    return tokens;
+};
+
+const clearTokens = () => {
+    localStorage.removeItem(ACCESS_TOKEN_KEY);
+    localStorage.removeItem(REFRESH_TOKEN_KEY);
+};
+
+// Sends the access token with every request.
+apiClient.interceptors.request.use((config) => {
+    const token = localStorage.getItem(ACCESS_TOKEN_KEY);
+    if (token) {
+        config.headers.Authorization = `Bearer ${token}`;
+    }
+    return config;
+});
+
+// On a 401, rotates the refresh token once and retries the request.
+apiClient.interceptors.response.use(undefined, async (error: AxiosError) => {
+    const original = error.config as (InternalAxiosRequestConfig & { _retried?: boolean }) | undefined;
+    const refreshToken = localStorage.getItem(REFRESH_TOKEN_KEY);
+    if (error.response?.status !== 401 || !original || original._retried || !refreshToken
+        || original.url?.startsWith('/auth/')) {
+        return Promise.reject(error);
+    }
+    original._retried = true;
+    try {
+        await refresh(refreshToken);
+    } catch {
+        clearTokens();
+        return Promise.reject(error);
+    }
+    return apiClient(original);
+});
+
+export const register = (email: string, name: string, password: string) =>
+    apiClient.post<TokenResponse>('/auth/register', { email, name, password }).then((res) => storeTokens(res.data));
+
+export const login = (email: string, password: string) =>
+    apiClient.post<TokenResponse>('/auth/login', { email, password }).then((res) => storeTokens(res.data));
+
+const refresh = (refreshToken: string) =>
+    apiClient.post<TokenResponse>('/auth/refresh', { refresh_token: refreshToken }).then((res) => storeTokens(res.data));
+
+export const logout = () => {
+    const refreshToken = localStorage.getItem(REFRESH_TOKEN_KEY);
+    clearTokens();
+    return refreshToken ? apiClient.post('/auth/logout', { refresh_token: refreshToken }) : Promise.resolve();
+};
+
+export const getCurrentUser = () =>
+    apiClient.get('/me');
+
 // Parameters for pagination.
 export const getProducts = (page: number, limit: number) =>
     apiClient.get(`/products?page=${page}&limit=${limit}`);
 
+// Name completions for search-as-you-type.
+export const suggestProducts = (prefix: string, limit = 10) =>
+    apiClient.get<ProductSuggestion[]>('/products/suggest', { params: { prefix, limit } });
+
 // Corrigido: Usando o tipo Product para mais segurança
 export const createProduct = (productData: Product) =>
     apiClient.post('/products', productData);
 
+// If-Match makes the API reject the write with 412 when someone else changed
+// the product since it was loaded.
+const ifMatch = (version?: number) =>
+    version ? { headers: { 'If-Match': `"${version}"` } } : {};
+
 export const updateProduct = (id: number, productData: Product) =>
-    apiClient.put(`/products/${id}`, productData);
+    apiClient.put(`/products/${id}`, productData, ifMatch(productData.version));
+
+// Changes only the given fields (RFC 7396 merge patch); null removes optional ones.
+export const patchProduct = (id: number, patch: Record<string, unknown>) =>
+    apiClient.patch(`/products/${id}`, patch, { headers: { 'Content-Type': 'application/merge-patch+json' } });
+
+// Deleting moves the product to the trash, from which it can be restored
+// until the retention period ends.
+export const deleteProduct = (id: number, version?: number) =>
+    apiClient.delete(`/products/${id}`, ifMatch(version));
+
+export const getTrash = (page = 1) =>
+    apiClient.get('/products/trash', { params: { page } });
 
-export const deleteProduct = (id: number) =>
-    apiClient.delete(`/products/${id}`);
\ No newline at end of file
+export const restoreProduct = (id: number) =>
+    apiClient.post(`/products/${id}/restore`);
@@ -1,7 +1,28 @@
+// Money mirrors the API's exact price representation: a decimal string
+// amount plus an ISO 4217 currency code.
+export interface Money {
+    amount: string;
+    currency: string;
+}
+
 export interface Product {
     id?: number;
     name: string;
-    price: number;
+    price: Money;
     amount: number;
     description?: string;
+    sku?: string;
+    // Generated from the name by the API when omitted.
+    slug?: string;
+    // Changes on every update; sent back as If-Match to detect concurrent edits.
+    version?: number;
+    // Set on products listed from the trash.
+    deleted_at?: string;
+}
+
+// ProductSuggestion is one name completion from GET /products/suggest.
+export interface ProductSuggestion {
+    id: number;
+    name: string;
+    slug: string;
 }
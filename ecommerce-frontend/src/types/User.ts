@@ -0,0 +1,15 @@
+export interface User {
+    id: number;
+    email: string;
+    name: string;
+    roles: string[];
+    created_at: string;
+}
+
+export interface TokenResponse {
+    access_token: string;
+    refresh_token: string;
+    token_type: string;
+    expires_in: number;
+    user: User;
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"e-commerce.com/internal/catalog"
+	"e-commerce.com/internal/storage"
+)
+
+const importUsage = "usage: import [-format csv|ndjson] [-report file] <file>"
+
+// runImportCommand implements the `import` subcommand: it loads a catalog
+// file like POST /products/import and writes the report of rejected rows to
+// -report, or to stderr.
+func runImportCommand(ctx context.Context, db *sql.DB, cfg config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", "", "file format, csv or ndjson (default: from the file extension)")
+	reportPath := flags.String("report", "", "write rejected rows to this CSV file instead of stderr")
+	if err := flags.Parse(args); err != nil {
+		return errors.New(importUsage)
+	}
+	if flags.NArg() != 1 {
+		return errors.New(importUsage)
+	}
+	path := flags.Arg(0)
+	if *format == "" {
+		*format = formatFromExtension(path)
+	}
+	if err := catalog.ValidateFormat(*format); err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func(file *os.File) {
+		err := file.Close()
+		if err != nil {
+			log.Printf("Error closing %s: %v", path, err)
+		}
+	}(file)
+
+	var out io.Writer = os.Stderr
+	if *reportPath != "" {
+		reportFile, err := os.Create(*reportPath)
+		if err != nil {
+			return err
+		}
+		defer func(reportFile *os.File) {
+			err := reportFile.Close()
+			if err != nil {
+				log.Printf("Error closing %s: %v", *reportPath, err)
+			}
+		}(reportFile)
+		out = reportFile
+	}
+	report, err := catalog.NewReportWriter(out)
+	if err != nil {
+		return err
+	}
+
+	repo := storage.NewProductRepository(db, cfg.QueryTimeout)
+	summary, err := catalog.Import(ctx, repo, file, *format, report.Write)
+	if flushErr := report.Flush(); err == nil {
+		err = flushErr
+	}
+	fmt.Printf("Read %d row(s): %d created, %d updated, %d rejected.\n", summary.Rows, summary.Created, summary.Updated, summary.Failed)
+	return err
+}
+
+// formatFromExtension guesses the format of a catalog file from its name.
+func formatFromExtension(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return catalog.FormatCSV
+	case ".ndjson", ".jsonl":
+		return catalog.FormatNDJSON
+	}
+	return ""
+}
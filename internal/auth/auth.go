@@ -0,0 +1,168 @@
+// Package auth verifies the JWT bearer tokens that authenticate API callers
+// and carries their claims through the request context.
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"e-commerce.com/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Roles understood by the API.
+const (
+	RoleAdmin          = "admin"
+	RoleCatalogManager = "catalog-manager"
+	RoleCustomer       = "customer"
+)
+
+// Claims are the JWT claims the API relies on. TokenUse is only set on
+// tokens issued by this API; tokens from an external issuer leave it empty.
+type Claims struct {
+	jwt.RegisteredClaims
+	Roles    []string `json:"roles,omitempty"`
+	TokenUse string   `json:"token_use,omitempty"`
+}
+
+// UserID returns the numeric subject of a token issued by this API.
+func (c *Claims) UserID() (int, bool) {
+	id, err := strconv.Atoi(c.Subject)
+	return id, err == nil
+}
+
+// HasAnyRole reports whether the claims grant at least one of roles.
+func (c *Claims) HasAnyRole(roles ...string) bool {
+	for _, have := range c.Roles {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Config selects the keys and expected claims used to verify tokens. At least
+// one of HMACSecret (HS256) or RSAPublicKey (RS256) must be set for any token
+// to be accepted. Empty Issuer or Audience are not checked.
+type Config struct {
+	HMACSecret   []byte
+	RSAPublicKey *rsa.PublicKey
+	Issuer       string
+	Audience     string
+}
+
+// Verifier validates bearer tokens against a Config.
+type Verifier struct {
+	cfg    Config
+	parser *jwt.Parser
+}
+
+// NewVerifier creates a Verifier for cfg.
+func NewVerifier(cfg Config) *Verifier {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"HS256", "RS256"}),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	return &Verifier{cfg: cfg, parser: jwt.NewParser(opts...)}
+}
+
+// Enabled reports whether any verification key is configured.
+func (v *Verifier) Enabled() bool {
+	return len(v.cfg.HMACSecret) > 0 || v.cfg.RSAPublicKey != nil
+}
+
+// Verify parses an access token, checks its signature, expiry, issuer and
+// audience, and returns its claims. Refresh tokens are rejected. Failures are
+// reported as domain.ErrUnauthorized.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	claims, err := v.parse(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenUse == TokenUseRefresh {
+		return nil, domain.NewUnauthorizedError("invalid token")
+	}
+	return claims, nil
+}
+
+// VerifyRefresh is Verify for refresh tokens: it only accepts tokens issued
+// by this API for refreshing.
+func (v *Verifier) VerifyRefresh(token string) (*Claims, error) {
+	claims, err := v.parse(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenUse != TokenUseRefresh || claims.ID == "" {
+		return nil, domain.NewUnauthorizedError("invalid refresh token")
+	}
+	return claims, nil
+}
+
+func (v *Verifier) parse(token string) (*Claims, error) {
+	if !v.Enabled() {
+		return nil, domain.NewUnauthorizedError("authentication is not configured")
+	}
+
+	claims := &Claims{}
+	_, err := v.parser.ParseWithClaims(token, claims, v.key)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, domain.NewUnauthorizedError("token has expired")
+		}
+		return nil, domain.NewUnauthorizedError("invalid token")
+	}
+	return claims, nil
+}
+
+// key picks the verification key matching the token's signing method, so a
+// token cannot choose an algorithm the server was not configured for.
+func (v *Verifier) key(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if len(v.cfg.HMACSecret) == 0 {
+			return nil, errors.New("HS256 tokens are not accepted")
+		}
+		return v.cfg.HMACSecret, nil
+	case *jwt.SigningMethodRSA:
+		if v.cfg.RSAPublicKey == nil {
+			return nil, errors.New("RS256 tokens are not accepted")
+		}
+		return v.cfg.RSAPublicKey, nil
+	default:
+		return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+	}
+}
+
+type contextKey struct{}
+
+// WithClaims returns a copy of ctx carrying claims.
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims of the authenticated caller, if any.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+	return claims, ok
+}
+
+// ActorFromContext names the authenticated caller for audit records, e.g.
+// "user:42", or returns "anonymous".
+func ActorFromContext(ctx context.Context) string {
+	if claims, ok := ClaimsFromContext(ctx); ok && claims.Subject != "" {
+		return "user:" + claims.Subject
+	}
+	return "anonymous"
+}
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"e-commerce.com/internal/domain"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultPasswordCost is the bcrypt work factor used for new password hashes.
+const DefaultPasswordCost = 12
+
+// Password length limits; bcrypt ignores anything past 72 bytes.
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+)
+
+// HashPassword derives a bcrypt hash of password with the given cost.
+func HashPassword(password string, cost int) (string, error) {
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return "", domain.NewValidationError("Invalid password: must be between 8 and 72 bytes long")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches hash.
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"e-commerce.com/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Token uses distinguish access tokens from refresh tokens, so one can never
+// be presented in place of the other.
+const (
+	TokenUseAccess  = "access"
+	TokenUseRefresh = "refresh"
+)
+
+// Default token lifetimes.
+const (
+	DefaultAccessTokenTTL  = 15 * time.Minute
+	DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// IssuerConfig selects the signing key and lifetimes of the tokens the API
+// issues itself. RSAPrivateKey (RS256) takes precedence over HMACSecret (HS256).
+type IssuerConfig struct {
+	HMACSecret      []byte
+	RSAPrivateKey   *rsa.PrivateKey
+	Issuer          string
+	Audience        string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// Issuer signs access and refresh tokens for authenticated users.
+type Issuer struct {
+	cfg    IssuerConfig
+	method jwt.SigningMethod
+	key    interface{}
+	now    func() time.Time
+}
+
+// NewIssuer creates an Issuer for cfg, applying the default lifetimes where
+// none are set.
+func NewIssuer(cfg IssuerConfig) *Issuer {
+	if cfg.AccessTokenTTL <= 0 {
+		cfg.AccessTokenTTL = DefaultAccessTokenTTL
+	}
+	if cfg.RefreshTokenTTL <= 0 {
+		cfg.RefreshTokenTTL = DefaultRefreshTokenTTL
+	}
+	i := &Issuer{cfg: cfg, now: time.Now}
+	switch {
+	case cfg.RSAPrivateKey != nil:
+		i.method, i.key = jwt.SigningMethodRS256, cfg.RSAPrivateKey
+	case len(cfg.HMACSecret) > 0:
+		i.method, i.key = jwt.SigningMethodHS256, cfg.HMACSecret
+	}
+	return i
+}
+
+// Enabled reports whether a signing key is configured.
+func (i *Issuer) Enabled() bool {
+	return i.method != nil
+}
+
+// AccessTokenTTL is how long issued access tokens stay valid.
+func (i *Issuer) AccessTokenTTL() time.Duration {
+	return i.cfg.AccessTokenTTL
+}
+
+// RefreshTokenTTL is how long issued refresh tokens stay valid.
+func (i *Issuer) RefreshTokenTTL() time.Duration {
+	return i.cfg.RefreshTokenTTL
+}
+
+// IssueAccessToken signs a short-lived token carrying the user's roles.
+func (i *Issuer) IssueAccessToken(user domain.User) (string, error) {
+	return i.sign(user.ID, TokenUseAccess, "", user.Roles, i.cfg.AccessTokenTTL)
+}
+
+// IssueRefreshToken signs a refresh token identified by tokenID, which must
+// match a stored domain.RefreshToken.
+func (i *Issuer) IssueRefreshToken(userID int, tokenID string, expiresAt time.Time) (string, error) {
+	return i.sign(userID, TokenUseRefresh, tokenID, nil, expiresAt.Sub(i.now()))
+}
+
+func (i *Issuer) sign(userID int, use, tokenID string, roles []string, ttl time.Duration) (string, error) {
+	if !i.Enabled() {
+		return "", errors.New("no token signing key configured")
+	}
+	now := i.now()
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(userID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			ID:        tokenID,
+		},
+		Roles:    roles,
+		TokenUse: use,
+	}
+	if i.cfg.Issuer != "" {
+		claims.Issuer = i.cfg.Issuer
+	}
+	if i.cfg.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{i.cfg.Audience}
+	}
+	return jwt.NewWithClaims(i.method, claims).SignedString(i.key)
+}
+
+// NewTokenID returns a random identifier for a refresh token or token family.
+func NewTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
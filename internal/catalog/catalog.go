@@ -0,0 +1,35 @@
+// Package catalog moves products in and out of the API in file formats
+// merchandisers work with: CSV, with one product per row, and NDJSON, with
+// one product JSON object per line.
+package catalog
+
+import (
+	"fmt"
+
+	"e-commerce.com/internal/domain"
+)
+
+// Supported file formats.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// ValidateFormat checks a format name taken from user input.
+func ValidateFormat(format string) error {
+	if format != FormatCSV && format != FormatNDJSON {
+		return domain.NewValidationError(fmt.Sprintf("Invalid format %q: must be csv or ndjson", format))
+	}
+	return nil
+}
+
+// Columns are the CSV columns, in the order exports write them. Imports
+// accept them in any order.
+var Columns = []string{"sku", "name", "description", "price", "currency", "amount", "slug"}
+
+// requiredColumns must be present in the header of an imported CSV file and
+// in every product of an NDJSON file. A row replaces every field of the
+// product with its SKU, so leaving one out would silently blank it. An empty
+// CSV currency means domain.DefaultCurrency; only the slug may be left out,
+// keeping the current one.
+var requiredColumns = []string{"sku", "name", "description", "price", "currency", "amount"}
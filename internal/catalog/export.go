@@ -0,0 +1,68 @@
+package catalog
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"e-commerce.com/internal/domain"
+)
+
+// Exporter writes products in one of the file formats. Output is buffered;
+// call Flush after the last product.
+type Exporter interface {
+	Write(p domain.Product) error
+	Flush() error
+}
+
+// NewExporter returns an Exporter writing format to w. CSV output starts
+// with a header row in the layout Import reads, but products without a SKU
+// are written with an empty sku and rejected if the file is imported again.
+func NewExporter(w io.Writer, format string) (Exporter, error) {
+	if err := ValidateFormat(format); err != nil {
+		return nil, err
+	}
+	buf := bufio.NewWriter(w)
+	if format == FormatNDJSON {
+		return &ndjsonExporter{buf: buf, enc: json.NewEncoder(buf)}, nil
+	}
+	cw := csv.NewWriter(buf)
+	if err := cw.Write(Columns); err != nil {
+		return nil, err
+	}
+	return &csvExporter{buf: buf, w: cw}, nil
+}
+
+type csvExporter struct {
+	buf *bufio.Writer
+	w   *csv.Writer
+}
+
+func (e *csvExporter) Write(p domain.Product) error {
+	// The order matches Columns.
+	return e.w.Write([]string{p.SKU, p.Name, p.Description, p.Price.String(), p.Price.Currency, strconv.Itoa(p.Amount), p.Slug})
+}
+
+func (e *csvExporter) Flush() error {
+	e.w.Flush()
+	if err := e.w.Error(); err != nil {
+		return err
+	}
+	return e.buf.Flush()
+}
+
+type ndjsonExporter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func (e *ndjsonExporter) Write(p domain.Product) error {
+	// Encode terminates every object with a newline.
+	return e.enc.Encode(p)
+}
+
+func (e *ndjsonExporter) Flush() error {
+	return e.buf.Flush()
+}
@@ -0,0 +1,51 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"e-commerce.com/internal/domain"
+	"e-commerce.com/internal/storage"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	source := []domain.Product{
+		{ID: 1, Name: "Mouse", Price: domain.NewMoney(1990, "USD"), Amount: 9, Description: "Seven buttons, \"RGB\"\nwireless", SKU: "MOUSE-1", Slug: "mouse", Version: 3},
+		{ID: 2, Name: "Cable", Price: domain.NewMoney(500, "BRL"), Amount: 0, SKU: "CABLE-1", Slug: "cable-2", Version: 1},
+	}
+
+	for _, format := range []string{FormatCSV, FormatNDJSON} {
+		t.Run(format, func(t *testing.T) {
+			var file bytes.Buffer
+			exporter, err := NewExporter(&file, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range source {
+				if err := exporter.Write(p); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := exporter.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			target := &storage.MockProductRepository{}
+			summary, err := Import(context.Background(), target, &file, format, func(e ImportError) error { return e.Err })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if summary.Created != len(source) {
+				t.Fatalf("expected %d products, got %+v", len(source), summary)
+			}
+			for i, p := range target.Products {
+				want := source[i]
+				want.ID, want.Version = p.ID, p.Version
+				if p != want {
+					t.Errorf("round trip changed the product:\ngot  %+v\nwant %+v", p, want)
+				}
+			}
+		})
+	}
+}
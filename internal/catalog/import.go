@@ -0,0 +1,316 @@
+package catalog
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"e-commerce.com/internal/domain"
+)
+
+// importBatchSize is how many rows are sent to the repository at once.
+const importBatchSize = 100
+
+// maxNDJSONLine bounds a single NDJSON line.
+const maxNDJSONLine = 1 << 20
+
+// ImportError reports a row that was not imported. Line is the row's
+// 1-based line number in the file.
+type ImportError struct {
+	Line int
+	SKU  string
+	Err  error
+}
+
+// ImportSummary counts the rows of an import by outcome.
+type ImportSummary struct {
+	Rows    int `json:"rows"`
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Failed  int `json:"failed"`
+}
+
+// Import reads products in format from r and upserts them by SKU, a batch at
+// a time, so memory use does not grow with the file. Rows are validated like
+// products created through the API and must have a SKU. Rows that fail are
+// passed to report in file order and do not stop the import; an error is
+// only returned when the file cannot be read further, a whole batch fails or
+// report fails.
+func Import(ctx context.Context, repo domain.ProductRepository, r io.Reader, format string, report func(ImportError) error) (ImportSummary, error) {
+	var summary ImportSummary
+	rows, err := newRowReader(r, format)
+	if err != nil {
+		return summary, err
+	}
+
+	pending := make([]importRow, 0, importBatchSize)
+	flush := func() error {
+		var (
+			operations []domain.BulkProductOperation
+			indexes    []int
+		)
+		for i := range pending {
+			if pending[i].err == nil {
+				operations = append(operations, domain.BulkProductOperation{Op: domain.BulkUpsert, Product: &pending[i].product})
+				indexes = append(indexes, i)
+			}
+		}
+		if len(operations) > 0 {
+			results, err := repo.Bulk(ctx, operations, false)
+			if err != nil {
+				return err
+			}
+			for j, result := range results {
+				switch result.Status {
+				case domain.BulkCreated:
+					summary.Created++
+				case domain.BulkUpdated:
+					summary.Updated++
+				default:
+					pending[indexes[j]].err = result.Err
+				}
+			}
+		}
+		for _, row := range pending {
+			if row.err == nil {
+				continue
+			}
+			summary.Failed++
+			if err := report(ImportError{Line: row.line, SKU: row.product.SKU, Err: row.err}); err != nil {
+				return err
+			}
+		}
+		pending = pending[:0]
+		return nil
+	}
+
+	for {
+		row, err := rows.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return summary, err
+		}
+		summary.Rows++
+		if row.err == nil {
+			row.err = validateImported(&row.product)
+		}
+		pending = append(pending, row)
+		if len(pending) == importBatchSize {
+			if err := flush(); err != nil {
+				return summary, err
+			}
+		}
+	}
+	return summary, flush()
+}
+
+// validateImported applies the rules of CreateProduct plus the SKU imports
+// match on. IDs and versions in the file are ignored.
+func validateImported(p *domain.Product) error {
+	if p.SKU == "" {
+		return domain.NewValidationError("Invalid product data: sku is required")
+	}
+	p.ID, p.Version, p.VariantSummary, p.DeletedAt = 0, 0, nil, nil
+	return p.Validate()
+}
+
+// importRow is one parsed row; err is set when it could not be parsed.
+type importRow struct {
+	line    int
+	product domain.Product
+	err     error
+}
+
+// rowReader yields rows until io.EOF.
+type rowReader interface {
+	next() (importRow, error)
+}
+
+func newRowReader(r io.Reader, format string) (rowReader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVRows(r)
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLine)
+		return &ndjsonRows{scanner: scanner}, nil
+	}
+	return nil, ValidateFormat(format)
+}
+
+type csvRows struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+// newCSVRows reads the header and checks its columns.
+func newCSVRows(r io.Reader) (*csvRows, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, domain.NewValidationError("Invalid CSV: the file is empty")
+	}
+	if err != nil {
+		return nil, domain.NewValidationError("Invalid CSV header: " + err.Error())
+	}
+
+	known := make(map[string]bool, len(Columns))
+	for _, c := range Columns {
+		known[c] = true
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Spreadsheet exports often start with a byte order mark.
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !known[name] {
+			return nil, domain.NewValidationError(fmt.Sprintf("Invalid CSV header: unknown column %q", name))
+		}
+		if _, dup := columns[name]; dup {
+			return nil, domain.NewValidationError(fmt.Sprintf("Invalid CSV header: duplicate column %q", name))
+		}
+		columns[name] = i
+	}
+	for _, name := range requiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, domain.NewValidationError(fmt.Sprintf("Invalid CSV header: missing column %q", name))
+		}
+	}
+	return &csvRows{reader: reader, columns: columns}, nil
+}
+
+func (c *csvRows) next() (importRow, error) {
+	record, err := c.reader.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return importRow{line: parseErr.StartLine, err: domain.NewValidationError("Invalid CSV row: " + parseErr.Err.Error())}, nil
+	}
+	if err != nil {
+		return importRow{}, err
+	}
+
+	line, _ := c.reader.FieldPos(0)
+	row := importRow{line: line}
+	if len(record) != len(c.columns) {
+		row.err = domain.NewValidationError(fmt.Sprintf("Invalid CSV row: expected %d fields, got %d", len(c.columns), len(record)))
+		return row, nil
+	}
+	field := func(name string) string {
+		if i, ok := c.columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	row.product = domain.Product{
+		SKU:         field("sku"),
+		Name:        field("name"),
+		Description: field("description"),
+		Slug:        field("slug"),
+	}
+	currency := field("currency")
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+	if row.product.Price, err = domain.ParseMoney(field("price"), strings.ToUpper(currency)); err != nil {
+		row.err = err
+		return row, nil
+	}
+	if row.product.Amount, err = strconv.Atoi(field("amount")); err != nil {
+		row.err = domain.NewValidationError(fmt.Sprintf("Invalid product data: amount %q is not a whole number", field("amount")))
+	}
+	return row, nil
+}
+
+type ndjsonRows struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (n *ndjsonRows) next() (importRow, error) {
+	for n.scanner.Scan() {
+		n.line++
+		data := n.scanner.Bytes()
+		if len(strings.TrimSpace(string(data))) == 0 {
+			continue
+		}
+		row := importRow{line: n.line}
+		if err := json.Unmarshal(data, &row.product); err != nil {
+			if !errors.Is(err, domain.ErrValidation) {
+				err = domain.NewValidationError("Invalid JSON: " + err.Error())
+			}
+			row.err = err
+		} else if missing := missingField(data); missing != "" {
+			row.err = domain.NewValidationError(fmt.Sprintf("Invalid product data: missing field %q", missing))
+		}
+		return row, nil
+	}
+	if err := n.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return importRow{}, domain.NewValidationError(fmt.Sprintf("Invalid NDJSON: line %d is longer than %d bytes", n.line+1, maxNDJSONLine))
+		}
+		return importRow{}, err
+	}
+	return importRow{}, io.EOF
+}
+
+// missingField returns the first of requiredColumns an NDJSON product leaves
+// out, like newCSVRows does for the header, or "" if it has them all. The
+// currency belongs in the price object; a bare price would fall back to
+// domain.DefaultCurrency.
+func missingField(data []byte) string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return ""
+	}
+	for _, name := range requiredColumns {
+		if name == "currency" {
+			var price map[string]json.RawMessage
+			if json.Unmarshal(fields["price"], &price) != nil || price["currency"] == nil {
+				return name
+			}
+			continue
+		}
+		if _, ok := fields[name]; !ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// ReportWriter writes rejected rows as CSV with the columns line, sku and
+// error.
+type ReportWriter struct {
+	w *csv.Writer
+}
+
+// NewReportWriter starts a report on w with its header row.
+func NewReportWriter(w io.Writer) (*ReportWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"line", "sku", "error"}); err != nil {
+		return nil, err
+	}
+	return &ReportWriter{w: cw}, nil
+}
+
+// Write adds one rejected row. Unclassified errors are reported without
+// their details, which may be internal.
+func (rw *ReportWriter) Write(e ImportError) error {
+	return rw.w.Write([]string{strconv.Itoa(e.Line), e.SKU, domain.ErrorMessage(e.Err, "internal error")})
+}
+
+// Flush writes any buffered rows.
+func (rw *ReportWriter) Flush() error {
+	rw.w.Flush()
+	return rw.w.Error()
+}
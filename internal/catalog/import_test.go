@@ -0,0 +1,114 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"e-commerce.com/internal/domain"
+	"e-commerce.com/internal/storage"
+)
+
+func TestImport_CSV(t *testing.T) {
+	products := &storage.MockProductRepository{Products: []domain.Product{
+		{ID: 1, Name: "Mouse", Price: domain.NewMoney(1990, "USD"), Amount: 9, SKU: "MOUSE-1", Slug: "mouse", Version: 1},
+	}}
+	file := "\ufeffSKU,name,price,currency,amount,description\n" +
+		"MOUSE-1,Gaming Mouse,24.90,USD,4,\"Seven buttons, RGB\"\n" +
+		"KB-1,Keyboard,49.90,,5,\n" +
+		",No SKU,1.00,,1,\n" +
+		"CABLE-1,Cable,abc,,1,\n" +
+		"CABLE-2,Cable,1.00,,-1,\n" +
+		"SHORT,Short row\n"
+
+	var rejected []ImportError
+	summary, err := Import(context.Background(), products, strings.NewReader(file), FormatCSV, func(e ImportError) error {
+		rejected = append(rejected, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := ImportSummary{Rows: 6, Created: 1, Updated: 1, Failed: 4}
+	if summary != want {
+		t.Errorf("expected summary %+v, got %+v", want, summary)
+	}
+	var lines []string
+	for _, e := range rejected {
+		if !errors.Is(e.Err, domain.ErrValidation) {
+			t.Errorf("expected a validation error on line %d, got %v", e.Line, e.Err)
+		}
+		lines = append(lines, fmt.Sprintf("%d:%s", e.Line, e.SKU))
+	}
+	if got := strings.Join(lines, ","); got != "4:,5:CABLE-1,6:CABLE-2,7:" {
+		t.Errorf("expected rejected lines 4 to 7, got %s", got)
+	}
+
+	mouse, _ := products.FindBySKU(context.Background(), "MOUSE-1")
+	if mouse.Name != "Gaming Mouse" || mouse.Price != domain.NewMoney(2490, "USD") || mouse.Description != "Seven buttons, RGB" || mouse.Slug != "mouse" {
+		t.Errorf("expected the mouse to be updated in place, got %+v", mouse)
+	}
+	keyboard, err := products.FindBySKU(context.Background(), "KB-1")
+	if err != nil || keyboard.Price != domain.NewMoney(4990, domain.DefaultCurrency) || keyboard.Slug != "keyboard" {
+		t.Errorf("expected the keyboard to be created in the default currency, got %+v, %v", keyboard, err)
+	}
+}
+
+func TestImport_NDJSON(t *testing.T) {
+	products := &storage.MockProductRepository{}
+	file := `{"id": 99, "sku": "KB-1", "name": "Keyboard", "description": "", "price": {"amount": "49.90", "currency": "USD"}, "amount": 5}
+
+{"sku": "KB-2", "name": "Keyboard", "description": "", "price": "not money", "amount": 5}
+{"sku": "KB-3", "name":
+{"sku": "KB-4", "name": "Keyboard", "price": {"amount": "49.90", "currency": "USD"}, "amount": 5}
+{"sku": "KB-5", "name": "Keyboard", "description": "", "price": "49.90", "amount": 5}
+`
+	var rejected []ImportError
+	summary, err := Import(context.Background(), products, strings.NewReader(file), FormatNDJSON, func(e ImportError) error {
+		rejected = append(rejected, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary != (ImportSummary{Rows: 5, Created: 1, Failed: 4}) {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	// Lines 5 and 6 leave out the description and the currency.
+	if len(rejected) != 4 || rejected[0].Line != 3 || rejected[1].Line != 4 || rejected[2].Line != 5 || rejected[3].Line != 6 {
+		t.Errorf("expected lines 3 to 6 to be rejected, got %+v", rejected)
+	}
+	if len(products.Products) != 1 || products.Products[0].ID == 99 {
+		t.Errorf("expected one product with a new ID, got %+v", products.Products)
+	}
+}
+
+func TestImport_BadHeader(t *testing.T) {
+	for _, file := range []string{"", "sku,name,price\n", "sku,name,price,amount\n", "sku,name,price,amount,colour\n", "sku,sku,name,price,amount\n"} {
+		_, err := Import(context.Background(), &storage.MockProductRepository{}, strings.NewReader(file), FormatCSV,
+			func(ImportError) error { return nil })
+		if !errors.Is(err, domain.ErrValidation) {
+			t.Errorf("expected a validation error for header %q, got %v", file, err)
+		}
+	}
+}
+
+func TestImport_Batches(t *testing.T) {
+	products := &storage.MockProductRepository{}
+	var file strings.Builder
+	file.WriteString("sku,name,description,price,currency,amount\n")
+	for i := 0; i < importBatchSize*2+5; i++ {
+		fmt.Fprintf(&file, "SKU-%d,Product %d,,1.00,USD,1\n", i, i)
+	}
+	summary, err := Import(context.Background(), products, strings.NewReader(file.String()), FormatCSV,
+		func(e ImportError) error { return e.Err })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Created != importBatchSize*2+5 || len(products.Products) != summary.Created {
+		t.Errorf("expected every row to be created, got %+v with %d products", summary, len(products.Products))
+	}
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Audited entities.
+const (
+	AuditEntityProduct = "product"
+	AuditEntityVariant = "variant"
+)
+
+// Audited actions.
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+	// AuditReserve and AuditRelease record stock taken by an order and
+	// returned when it is cancelled or refunded.
+	AuditReserve = "reserve"
+	AuditRelease = "release"
+)
+
+// AuditActorSystem is the actor of changes made without an authenticated
+// caller, by background jobs and commands.
+const AuditActorSystem = "system"
+
+// AuditEntry records one change to an entity: who made it, in which request
+// and what it changed.
+type AuditEntry struct {
+	ID       int64  `json:"id"`
+	Entity   string `json:"entity"`
+	EntityID string `json:"entity_id"`
+	Action   string `json:"action"`
+	// Actor is auth.ActorFromContext of the request, or AuditActorSystem.
+	Actor     string `json:"actor"`
+	RequestID string `json:"request_id,omitempty"`
+	// Before and After hold the fields that changed, as JSON objects. A
+	// create has no Before and a purge no After.
+	Before    json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After     json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditRepository reads the audit log. Entries are written by the
+// repositories of the audited entities, in the transaction of each change,
+// and never modified.
+type AuditRepository interface {
+	// List returns one page of the entries for an entity, newest first, and
+	// the total number of entries. An empty entityID matches every entity
+	// of the kind.
+	List(ctx context.Context, entity, entityID string, page, limit int) ([]AuditEntry, int, error)
+}
+
+// auditIgnoredFields change with every write or are derived, so they would
+// only add noise to the diffs.
+var auditIgnoredFields = []string{"version", "variant_summary"}
+
+// AuditDiff returns the top-level JSON fields of before and after that
+// differ. Fields missing on one side are reported as null there. When before
+// or after is nil, the other side is returned whole.
+func AuditDiff(before, after interface{}) (json.RawMessage, json.RawMessage, error) {
+	if before == nil || after == nil {
+		b, err := marshalAuditSide(before)
+		if err != nil {
+			return nil, nil, err
+		}
+		a, err := marshalAuditSide(after)
+		return b, a, err
+	}
+
+	b, err := auditFields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	a, err := auditFields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+	null := json.RawMessage("null")
+	changedBefore := map[string]json.RawMessage{}
+	changedAfter := map[string]json.RawMessage{}
+	for key, value := range b {
+		if other, ok := a[key]; !ok || !bytes.Equal(value, other) {
+			changedBefore[key] = value
+			changedAfter[key] = null
+		}
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || !bytes.Equal(value, other) {
+			changedAfter[key] = value
+			if !ok {
+				changedBefore[key] = null
+			}
+		}
+	}
+
+	beforeJSON, err := json.Marshal(changedBefore)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterJSON, err := json.Marshal(changedAfter)
+	return beforeJSON, afterJSON, err
+}
+
+func marshalAuditSide(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+func auditFields(v interface{}) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, name := range auditIgnoredFields {
+		delete(fields, name)
+	}
+	return fields, nil
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditDiff(t *testing.T) {
+	before := Product{ID: 1, Name: "Mouse", Price: NewMoney(1990, "USD"), Amount: 9, Slug: "mouse", Version: 1}
+	after := before
+	after.Amount, after.Description, after.Version = 7, "Wireless", 2
+
+	b, a, err := AuditDiff(before, after)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := string(b), `{"amount":9,"description":""}`; got != want {
+		t.Errorf("before = %s, want %s", got, want)
+	}
+	if got, want := string(a), `{"amount":7,"description":"Wireless"}`; got != want {
+		t.Errorf("after = %s, want %s", got, want)
+	}
+
+	deleted := before
+	deleted.DeletedAt = &time.Time{}
+	b, _, err = AuditDiff(before, deleted)
+	if err != nil || string(b) != `{"deleted_at":null}` {
+		t.Errorf("expected a field missing before to be null there, got %s, %v", b, err)
+	}
+
+	b, a, err = AuditDiff(nil, after)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b != nil {
+		t.Errorf("expected no before for a create, got %s", b)
+	}
+	if got, want := string(a), `"name":"Mouse"`; !strings.Contains(got, want) {
+		t.Errorf("expected the whole product after a create, got %s", got)
+	}
+
+	b, a, err = AuditDiff(before, before)
+	if err != nil || string(b) != "{}" || string(a) != "{}" {
+		t.Errorf("expected empty diffs for an unchanged product, got %s, %s, %v", b, a, err)
+	}
+}
@@ -0,0 +1,82 @@
+package domain
+
+import "fmt"
+
+// Bulk operation kinds.
+const (
+	BulkUpsert = "upsert"
+	BulkDelete = "delete"
+)
+
+// Outcomes of a single bulk operation.
+const (
+	BulkCreated = "created"
+	BulkUpdated = "updated"
+	BulkDeleted = "deleted"
+	BulkFailed  = "failed"
+	// BulkRolledBack marks an operation that succeeded but was undone
+	// because a later one failed in atomic mode.
+	BulkRolledBack = "rolled_back"
+	// BulkSkipped marks an operation that was never attempted because an
+	// earlier one failed in atomic mode.
+	BulkSkipped = "skipped"
+)
+
+// MaxBulkOperations bounds the number of operations in one bulk request.
+const MaxBulkOperations = 1000
+
+// BulkProductOperation is one item of a bulk product request.
+//
+// An upsert updates the product with Product.ID or, when the ID is zero, the
+// product with Product.SKU, and creates a new product when the ID is zero and
+// no product has the SKU. Product.Version is checked like in Update. A delete
+// removes the product with ID, provided its version equals Version (zero
+// matches any version).
+type BulkProductOperation struct {
+	Op      string   `json:"op"`
+	Product *Product `json:"product,omitempty"`
+	ID      int      `json:"id,omitempty"`
+	Version int      `json:"version,omitempty"`
+}
+
+// Validate checks the operation and, for upserts, the product.
+func (op *BulkProductOperation) Validate() error {
+	switch op.Op {
+	case BulkUpsert:
+		if op.Product == nil {
+			return NewValidationError("Invalid operation: upsert requires a product")
+		}
+		op.Product.VariantSummary, op.Product.DeletedAt = nil, nil
+		return op.Product.Validate()
+	case BulkDelete:
+		if op.ID <= 0 {
+			return NewValidationError("Invalid operation: delete requires a product id")
+		}
+		return nil
+	}
+	return NewValidationError(fmt.Sprintf("Invalid operation %q: must be upsert or delete", op.Op))
+}
+
+// BulkProductResult reports the outcome of one bulk operation. Product is
+// the stored product after a successful upsert.
+type BulkProductResult struct {
+	Status  string
+	Product *Product
+	Err     error
+}
+
+// AbortBulk rewrites results after operation failed with err in atomic
+// mode: the operations before it were rolled back and the ones after it were
+// never attempted.
+func AbortBulk(results []BulkProductResult, failed int, err error) {
+	for i := range results {
+		switch {
+		case i < failed:
+			results[i] = BulkProductResult{Status: BulkRolledBack}
+		case i == failed:
+			results[i] = BulkProductResult{Status: BulkFailed, Err: err}
+		default:
+			results[i] = BulkProductResult{Status: BulkSkipped}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Cart is a shopping cart owned either by a registered user or, when UserID
+// is nil, by whoever holds its ID, which then acts as an anonymous session
+// token.
+type Cart struct {
+	ID        string     `json:"id"`
+	UserID    *int       `json:"user_id"`
+	Items     []CartItem `json:"items"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// CartItem is a line of a cart. Name and UnitPrice are snapshotted when the
+// product is first added, so later catalog changes do not alter the cart.
+type CartItem struct {
+	ProductID int    `json:"product_id"`
+	Name      string `json:"name"`
+	UnitPrice Money  `json:"unit_price"`
+	Quantity  int    `json:"quantity"`
+}
+
+// Subtotal is the unit price times the quantity.
+func (i CartItem) Subtotal() Money {
+	return i.UnitPrice.Mul(i.Quantity)
+}
+
+// MarshalJSON adds the computed subtotal to the item.
+func (i CartItem) MarshalJSON() ([]byte, error) {
+	type item CartItem
+	return json.Marshal(struct {
+		item
+		Subtotal Money `json:"subtotal"`
+	}{item(i), i.Subtotal()})
+}
+
+// Total sums the subtotals of every item. An empty cart totals zero in
+// DefaultCurrency.
+func (c *Cart) Total() Money {
+	total := Money{Currency: DefaultCurrency}
+	for i, item := range c.Items {
+		if i == 0 {
+			total.Currency = item.UnitPrice.Currency
+		}
+		// AddItem keeps every item in one currency, so this cannot fail.
+		total, _ = total.Add(item.Subtotal())
+	}
+	return total
+}
+
+// MarshalJSON adds the computed total to the cart.
+func (c Cart) MarshalJSON() ([]byte, error) {
+	type cart Cart
+	if c.Items == nil {
+		c.Items = []CartItem{}
+	}
+	return json.Marshal(struct {
+		cart
+		Total Money `json:"total"`
+	}{cart(c), c.Total()})
+}
+
+// AddItem adds quantity units of product, or increases the quantity if the
+// product is already in the cart. The cart may not hold more units than are
+// in stock, nor mix currencies.
+func (c *Cart) AddItem(product Product, quantity int) error {
+	if quantity <= 0 {
+		return NewValidationError("quantity must be positive")
+	}
+	if idx := c.indexOf(product.ID); idx != -1 {
+		return c.setQuantity(idx, product, c.Items[idx].Quantity+quantity)
+	}
+	if len(c.Items) > 0 && c.Items[0].UnitPrice.Currency != product.Price.Currency {
+		return NewValidationError(fmt.Sprintf("cannot add a %s product to a %s cart", product.Price.Currency, c.Items[0].UnitPrice.Currency))
+	}
+	if err := checkStock(product, quantity); err != nil {
+		return err
+	}
+	c.Items = append(c.Items, CartItem{
+		ProductID: product.ID,
+		Name:      product.Name,
+		UnitPrice: product.Price,
+		Quantity:  quantity,
+	})
+	return nil
+}
+
+// UpdateItem sets the quantity of a product already in the cart.
+func (c *Cart) UpdateItem(product Product, quantity int) error {
+	if quantity <= 0 {
+		return NewValidationError("quantity must be positive")
+	}
+	idx := c.indexOf(product.ID)
+	if idx == -1 {
+		return NewNotFoundError("product is not in the cart")
+	}
+	return c.setQuantity(idx, product, quantity)
+}
+
+// RemoveItem removes a product from the cart.
+func (c *Cart) RemoveItem(productID int) error {
+	idx := c.indexOf(productID)
+	if idx == -1 {
+		return NewNotFoundError("product is not in the cart")
+	}
+	c.Items = append(c.Items[:idx], c.Items[idx+1:]...)
+	return nil
+}
+
+func (c *Cart) setQuantity(idx int, product Product, quantity int) error {
+	if err := checkStock(product, quantity); err != nil {
+		return err
+	}
+	c.Items[idx].Quantity = quantity
+	return nil
+}
+
+func (c *Cart) indexOf(productID int) int {
+	for i, item := range c.Items {
+		if item.ProductID == productID {
+			return i
+		}
+	}
+	return -1
+}
+
+func checkStock(product Product, quantity int) error {
+	if quantity > product.Amount {
+		return NewConflictError(fmt.Sprintf("only %d units of %q are in stock", product.Amount, product.Name), nil)
+	}
+	return nil
+}
+
+// CartRepository is implemented by every cart store.
+type CartRepository interface {
+	// Create stores a new cart and assigns its ID. A user can only own one cart.
+	Create(ctx context.Context, cart *Cart) error
+	FindByID(ctx context.Context, id string) (Cart, error)
+	FindByUser(ctx context.Context, userID int) (Cart, error)
+	// Update applies change to the stored cart and replaces its items with
+	// the result. The cart is locked meanwhile, so concurrent changes are
+	// applied one after the other and none is lost. If change fails, the cart
+	// is left as it was.
+	Update(ctx context.Context, id string, change func(cart *Cart) error) (Cart, error)
+	Delete(ctx context.Context, id string) error
+}
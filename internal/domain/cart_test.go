@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCartItems(t *testing.T) {
+	mug := Product{ID: 1, Name: "Mug", Price: NewMoney(1990, "BRL"), Amount: 3}
+	pen := Product{ID: 2, Name: "Pen", Price: NewMoney(250, "BRL"), Amount: 10}
+	var cart Cart
+
+	if err := cart.AddItem(mug, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := cart.AddItem(pen, 4); err != nil {
+		t.Fatal(err)
+	}
+	if err := cart.AddItem(mug, 2); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected a conflict when exceeding stock, got %v", err)
+	}
+	if err := cart.AddItem(pen, 0); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected a validation error for a zero quantity, got %v", err)
+	}
+	if err := cart.AddItem(Product{ID: 3, Name: "Fan", Price: NewMoney(500, "USD"), Amount: 1}, 1); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected a validation error when mixing currencies, got %v", err)
+	}
+
+	// Price changes after adding do not affect the snapshot.
+	mug.Price = NewMoney(2990, "BRL")
+	if err := cart.AddItem(mug, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := cart.Total(); got != NewMoney(3*1990+4*250, "BRL") {
+		t.Errorf("expected total 69.70 BRL, got %s %s", got, got.Currency)
+	}
+
+	if err := cart.UpdateItem(pen, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := cart.RemoveItem(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := cart.RemoveItem(1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found when removing a missing item, got %v", err)
+	}
+	if got := cart.Total(); got != NewMoney(250, "BRL") {
+		t.Errorf("expected total 2.50 BRL, got %s %s", got, got.Currency)
+	}
+}
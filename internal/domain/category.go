@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"context"
+	"sort"
+)
+
+// Category groups products. Categories form a tree through ParentID.
+type Category struct {
+	ID       int        `json:"id"`
+	Name     string     `json:"name"`
+	ParentID *int       `json:"parent_id"`
+	Children []Category `json:"children,omitempty"`
+}
+
+// Validate checks the invariants every stored category must satisfy.
+func (c *Category) Validate() error {
+	if c.Name == "" {
+		return NewValidationError("Invalid category data: name is required")
+	}
+	if c.ParentID != nil && *c.ParentID == c.ID && c.ID != 0 {
+		return NewValidationError("Invalid category data: a category cannot be its own parent")
+	}
+	return nil
+}
+
+// BuildCategoryTree nests a flat list of categories under their parents and
+// returns the roots. Categories whose parent is not in the list are treated
+// as roots. Siblings are ordered by ID.
+func BuildCategoryTree(categories []Category) []Category {
+	children := make(map[int][]Category)
+	known := make(map[int]bool, len(categories))
+	for _, c := range categories {
+		known[c.ID] = true
+	}
+
+	var roots []Category
+	for _, c := range categories {
+		if c.ParentID != nil && known[*c.ParentID] {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		} else {
+			roots = append(roots, c)
+		}
+	}
+
+	var attach func(nodes []Category) []Category
+	attach = func(nodes []Category) []Category {
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+		for i := range nodes {
+			nodes[i].Children = attach(children[nodes[i].ID])
+		}
+		return nodes
+	}
+	return attach(roots)
+}
+
+// CategoryRepository is implemented by every category store.
+type CategoryRepository interface {
+	Save(ctx context.Context, category *Category) error
+	FindAll(ctx context.Context) ([]Category, error)
+	FindByID(ctx context.Context, id int) (Category, error)
+	// Update fails with a validation error if the new parent would create a cycle.
+	Update(ctx context.Context, category *Category) error
+	// Delete fails with a conflict error while the category still has children.
+	Delete(ctx context.Context, id int) error
+	// Descendants returns id followed by the IDs of every category below it.
+	Descendants(ctx context.Context, id int) ([]int, error)
+	AssignProduct(ctx context.Context, categoryID, productID int) error
+	UnassignProduct(ctx context.Context, categoryID, productID int) error
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "testing"
+
+func TestBuildCategoryTree(t *testing.T) {
+	one, two := 1, 2
+	categories := []Category{
+		{ID: 3, Name: "Mice", ParentID: &two},
+		{ID: 1, Name: "Electronics"},
+		{ID: 2, Name: "Accessories", ParentID: &one},
+		{ID: 4, Name: "Furniture"},
+	}
+
+	roots := BuildCategoryTree(categories)
+
+	if len(roots) != 2 || roots[0].ID != 1 || roots[1].ID != 4 {
+		t.Fatalf("unexpected roots: %+v", roots)
+	}
+	if len(roots[0].Children) != 1 || roots[0].Children[0].ID != 2 {
+		t.Fatalf("unexpected children of Electronics: %+v", roots[0].Children)
+	}
+	if len(roots[0].Children[0].Children) != 1 || roots[0].Children[0].Children[0].ID != 3 {
+		t.Errorf("unexpected children of Accessories: %+v", roots[0].Children[0].Children)
+	}
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+)
+
+// ProductCursor marks a position in a sorted product listing for keyset
+// pagination. It records the sort key of the boundary row rather than an
+// offset, so pages stay stable when products are inserted or deleted.
+type ProductCursor struct {
+	Sort ProductSort
+	// Value is the boundary row's sort column rendered as text; unused when sorting by ID.
+	Value string
+	ID    int
+	// Before selects the rows preceding the boundary instead of those following it.
+	Before bool
+}
+
+type cursorJSON struct {
+	Field      string `json:"f"`
+	Descending bool   `json:"d,omitempty"`
+	Value      string `json:"v,omitempty"`
+	ID         int    `json:"id"`
+	Before     bool   `json:"b,omitempty"`
+}
+
+// NewProductCursor returns a cursor positioned at p in a listing ordered by sort.
+func NewProductCursor(p Product, sort ProductSort, before bool) ProductCursor {
+	c := ProductCursor{Sort: sort, ID: p.ID, Before: before}
+	switch sort.Field {
+	case ProductSortName:
+		c.Value = p.Name
+	case ProductSortPrice:
+		c.Value = p.Price.String()
+	case ProductSortAmount:
+		c.Value = strconv.Itoa(p.Amount)
+	}
+	return c
+}
+
+// Encode returns the opaque string handed to API clients.
+func (c ProductCursor) Encode() string {
+	data, _ := json.Marshal(cursorJSON{
+		Field:      c.Sort.Field,
+		Descending: c.Sort.Descending,
+		Value:      c.Value,
+		ID:         c.ID,
+		Before:     c.Before,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeProductCursor parses a string produced by Encode. It rejects cursors
+// that were not issued for the given sort order.
+func DecodeProductCursor(s string, sort ProductSort) (ProductCursor, error) {
+	invalid := NewValidationError("Invalid cursor")
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return ProductCursor{}, invalid
+	}
+	var v cursorJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return ProductCursor{}, invalid
+	}
+	if v.Field == "" {
+		v.Field = ProductSortID
+	}
+	if v.Field != sort.Field || v.Descending != sort.Descending {
+		return ProductCursor{}, NewValidationError("Cursor does not match the requested sort order")
+	}
+
+	switch v.Field {
+	case ProductSortPrice:
+		if _, err := parseDecimal(v.Value, priceScale); err != nil {
+			return ProductCursor{}, invalid
+		}
+	case ProductSortAmount:
+		if _, err := strconv.Atoi(v.Value); err != nil {
+			return ProductCursor{}, invalid
+		}
+	}
+
+	return ProductCursor{Sort: sort, Value: v.Value, ID: v.ID, Before: v.Before}, nil
+}
@@ -0,0 +1,94 @@
+package domain
+
+import "errors"
+
+// Error kinds shared by every repository and handler. Callers classify an
+// error with errors.Is(err, domain.ErrNotFound) instead of comparing messages.
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrValidation      = errors.New("validation failed")
+	ErrUnavailable     = errors.New("service unavailable")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrPaymentDeclined = errors.New("payment declined")
+	// ErrPreconditionFailed reports that the caller's copy of an entity is
+	// stale, e.g. an If-Match version that no longer matches.
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// Error carries one of the error kinds above, a message that is safe to show
+// to API clients and, optionally, the underlying cause.
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap exposes both the kind and the cause to errors.Is and errors.As.
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// NewNotFoundError reports that the requested entity does not exist.
+func NewNotFoundError(message string) error {
+	return &Error{Kind: ErrNotFound, Message: message}
+}
+
+// NewConflictError reports that the change clashes with the current state,
+// e.g. a unique constraint.
+func NewConflictError(message string, cause error) error {
+	return &Error{Kind: ErrConflict, Message: message, Err: cause}
+}
+
+// NewValidationError reports that the input is malformed or breaks a rule.
+func NewValidationError(message string) error {
+	return &Error{Kind: ErrValidation, Message: message}
+}
+
+// NewUnavailableError reports that a dependency (usually the database) could
+// not serve the request.
+func NewUnavailableError(message string, cause error) error {
+	return &Error{Kind: ErrUnavailable, Message: message, Err: cause}
+}
+
+// NewUnauthorizedError reports missing or invalid credentials.
+func NewUnauthorizedError(message string) error {
+	return &Error{Kind: ErrUnauthorized, Message: message}
+}
+
+// NewForbiddenError reports that the caller may not perform the action.
+func NewForbiddenError(message string) error {
+	return &Error{Kind: ErrForbidden, Message: message}
+}
+
+// NewPaymentDeclinedError reports that the payment provider refused a charge.
+func NewPaymentDeclinedError(message string) error {
+	return &Error{Kind: ErrPaymentDeclined, Message: message}
+}
+
+// NewPreconditionFailedError reports that the entity changed since the
+// caller last read it.
+func NewPreconditionFailedError(message string) error {
+	return &Error{Kind: ErrPreconditionFailed, Message: message}
+}
+
+// ErrorMessage returns the client-safe message of a domain error, or
+// fallback when err is not one.
+func ErrorMessage(err error, fallback string) string {
+	var de *Error
+	if errors.As(err, &de) && de.Message != "" {
+		return de.Message
+	}
+	return fallback
+}
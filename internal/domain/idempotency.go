@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotentResponse is a stored response replayed for a repeated
+// Idempotency-Key.
+type IdempotentResponse struct {
+	Status int
+	Header map[string]string
+	Body   []byte
+}
+
+// IdempotencyRecord tracks one Idempotency-Key of one caller. Response is nil
+// while the first request with the key is still being processed.
+type IdempotencyRecord struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	Response    *IdempotentResponse
+	ExpiresAt   time.Time
+}
+
+// IdempotencyRepository stores idempotency keys. Keys are scoped, usually to
+// the caller, so clients cannot collide with each other's keys.
+type IdempotencyRepository interface {
+	// Begin claims the key for a new request. It returns nil when the claim
+	// succeeded, or the existing unexpired record when the key is taken.
+	// Expired records are replaced.
+	Begin(ctx context.Context, record IdempotencyRecord) (*IdempotencyRecord, error)
+	// Complete stores the response of the request that claimed the key.
+	Complete(ctx context.Context, scope, key string, response IdempotentResponse) error
+	// Release forgets a claimed key, so a request that failed can be retried.
+	Release(ctx context.Context, scope, key string) error
+	// DeleteExpired removes expired records and returns how many it removed.
+	DeleteExpired(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,239 @@
+package domain
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is assumed when a price is given without a currency.
+const DefaultCurrency = "BRL"
+
+// MaxPrice is the largest amount, in minor units, that fits the NUMERIC(10, 2) price columns.
+const MaxPrice = 99999999_99
+
+// priceScale is the number of decimal places stored in NUMERIC(10, 2) columns.
+const priceScale = 2
+
+// currencyExponents lists the supported ISO 4217 currencies and the number of
+// digits of their minor unit.
+var currencyExponents = map[string]int{
+	"BRL": 2,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"ARS": 2,
+	"CLP": 0,
+	"JPY": 0,
+}
+
+// Money is an exact monetary amount: an integer number of minor units
+// (cents for BRL) plus an ISO 4217 currency code. It never goes through
+// floating point, so 19.99 stays 19.99.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// NewMoney returns amount minor units of currency.
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney parses a decimal string such as "19.99" into Money. It fails if
+// the value has more decimal places than the currency's minor unit.
+func ParseMoney(value, currency string) (Money, error) {
+	exp, ok := currencyExponents[currency]
+	if !ok {
+		return Money{}, NewValidationError(fmt.Sprintf("unsupported currency %q", currency))
+	}
+	amount, err := parseDecimal(value, exp)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// String formats the amount as a plain decimal, e.g. "19.99".
+func (m Money) String() string {
+	return formatDecimal(m.Amount, m.exponent())
+}
+
+// IsZero reports whether m is the zero value, i.e. no price was given at all.
+func (m Money) IsZero() bool {
+	return m == Money{}
+}
+
+// Validate checks that m has a supported currency and a non-negative amount
+// that fits the database column.
+func (m Money) Validate() error {
+	if _, ok := currencyExponents[m.Currency]; !ok {
+		return NewValidationError(fmt.Sprintf("unsupported currency %q", m.Currency))
+	}
+	if m.Amount < 0 {
+		return NewValidationError("price must not be negative")
+	}
+	if m.Amount*pow10(priceScale-m.exponent()) > MaxPrice {
+		return NewValidationError("price exceeds the maximum allowed value")
+	}
+	return nil
+}
+
+// Add returns m + o. Both must share the same currency.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, NewValidationError(fmt.Sprintf("cannot add %s to %s", o.Currency, m.Currency))
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+// Mul returns m multiplied by an integer quantity.
+func (m Money) Mul(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+func (m Money) exponent() int {
+	if exp, ok := currencyExponents[m.Currency]; ok {
+		return exp
+	}
+	return priceScale
+}
+
+// Scan implements sql.Scanner for NUMERIC columns. Only the amount is read;
+// the currency lives in its own column and must be scanned first, because it
+// decides how many minor units the decimal is converted to. DefaultCurrency
+// is used when it is unset.
+func (m *Money) Scan(src interface{}) error {
+	if m.Currency == "" {
+		m.Currency = DefaultCurrency
+	}
+	var text string
+	switch v := src.(type) {
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+	// NUMERIC(10, 2) always has exactly priceScale decimals; rescale to the currency.
+	amount, err := parseDecimal(text, priceScale)
+	if err != nil {
+		return err
+	}
+	m.Amount = amount / pow10(priceScale-m.exponent())
+	return nil
+}
+
+// Value implements driver.Valuer, writing the amount as an exact decimal string.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON encodes m as {"amount": "19.99", "currency": "BRL"}. The amount
+// is a string so JavaScript clients cannot lose precision.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.String(), Currency: m.Currency})
+}
+
+// UnmarshalJSON accepts the structured form produced by MarshalJSON, a
+// decimal string ("19.99") or a bare JSON number (19.99). The latter two use
+// DefaultCurrency. Numbers are parsed from their literal text, never as floats.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*m = Money{}
+		return nil
+	}
+
+	var value, currency string
+	switch data[0] {
+	case '{':
+		var v moneyJSON
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		value, currency = v.Amount, v.Currency
+	case '"':
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+	default:
+		value = string(data)
+	}
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	parsed, err := ParseMoney(value, currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// parseDecimal converts a decimal string to an integer scaled by 10^scale,
+// rejecting anything that would need rounding.
+func parseDecimal(value string, scale int) (int64, error) {
+	invalid := NewValidationError(fmt.Sprintf("invalid monetary amount %q", value))
+
+	s := strings.TrimSpace(value)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, invalid
+	}
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > scale {
+		return 0, NewValidationError(fmt.Sprintf("monetary amount %q has more than %d decimal places", value, scale))
+	}
+	digits := whole + frac + strings.Repeat("0", scale-len(frac))
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return 0, invalid
+		}
+	}
+
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, invalid
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+func formatDecimal(amount int64, scale int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if scale == 0 {
+		return sign + strconv.FormatInt(amount, 10)
+	}
+	unit := pow10(scale)
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/unit, scale, amount%unit)
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+	return p
+}
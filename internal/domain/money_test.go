@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Money
+	}{
+		{`19.99`, NewMoney(1999, "BRL")},
+		{`"0.1"`, NewMoney(10, "BRL")},
+		{`150`, NewMoney(15000, "BRL")},
+		{`{"amount":"1234.50","currency":"USD"}`, NewMoney(123450, "USD")},
+		{`{"amount":"500","currency":"JPY"}`, NewMoney(500, "JPY")},
+	}
+
+	for _, tt := range tests {
+		var got Money
+		if err := json.Unmarshal([]byte(tt.input), &got); err != nil {
+			t.Errorf("Unmarshal(%s) returned an error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Unmarshal(%s) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyUnmarshalJSON_Invalid(t *testing.T) {
+	for _, input := range []string{`19.999`, `"abc"`, `1e3`, `{"amount":"1","currency":"XXX"}`, `{"amount":"1.5","currency":"JPY"}`} {
+		var m Money
+		err := json.Unmarshal([]byte(input), &m)
+		if !errors.Is(err, ErrValidation) {
+			t.Errorf("Unmarshal(%s) error = %v, want a validation error", input, err)
+		}
+	}
+}
+
+func TestMoneyMarshalJSON(t *testing.T) {
+	got, err := json.Marshal(NewMoney(1999, "BRL"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"amount":"19.99","currency":"BRL"}`; string(got) != want {
+		t.Errorf("Marshal = %s, want %s", got, want)
+	}
+}
+
+func TestMoneyScanAndValue(t *testing.T) {
+	m := Money{Currency: "BRL"}
+	if err := m.Scan([]byte("19.99")); err != nil {
+		t.Fatal(err)
+	}
+	if m.Amount != 1999 {
+		t.Errorf("Scan amount = %d, want 1999", m.Amount)
+	}
+
+	jpy := Money{Currency: "JPY"}
+	if err := jpy.Scan([]byte("500.00")); err != nil {
+		t.Fatal(err)
+	}
+	if jpy.Amount != 500 {
+		t.Errorf("Scan JPY amount = %d, want 500", jpy.Amount)
+	}
+
+	v, err := m.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "19.99" {
+		t.Errorf("Value = %v, want 19.99", v)
+	}
+}
+
+func TestMoneyValidate(t *testing.T) {
+	if err := NewMoney(-1, "BRL").Validate(); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected negative amount to be rejected, got %v", err)
+	}
+	if err := NewMoney(MaxPrice+1, "BRL").Validate(); !errors.Is(err, ErrValidation) {
+		t.Errorf("expected oversized amount to be rejected, got %v", err)
+	}
+	if err := NewMoney(MaxPrice, "BRL").Validate(); err != nil {
+		t.Errorf("expected maximum amount to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// OrderStatus is the lifecycle state of an order.
+type OrderStatus string
+
+// Order states. An order starts out pending and normally moves through
+// paid, fulfilled and shipped to delivered. Pending orders can be cancelled;
+// paid orders, whether or not they were shipped, can be refunded.
+const (
+	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusPaid      OrderStatus = "paid"
+	OrderStatusFulfilled OrderStatus = "fulfilled"
+	OrderStatusShipped   OrderStatus = "shipped"
+	OrderStatusDelivered OrderStatus = "delivered"
+	OrderStatusCancelled OrderStatus = "cancelled"
+	OrderStatusRefunded  OrderStatus = "refunded"
+)
+
+// orderTransitions lists the states each state may move to.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:   {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:      {OrderStatusFulfilled, OrderStatusRefunded},
+	OrderStatusFulfilled: {OrderStatusShipped, OrderStatusRefunded},
+	OrderStatusShipped:   {OrderStatusDelivered, OrderStatusRefunded},
+	OrderStatusDelivered: {OrderStatusRefunded},
+}
+
+// ParseOrderStatus validates a status name.
+func ParseOrderStatus(s string) (OrderStatus, error) {
+	status := OrderStatus(s)
+	switch status {
+	case OrderStatusPending, OrderStatusPaid, OrderStatusFulfilled, OrderStatusShipped,
+		OrderStatusDelivered, OrderStatusCancelled, OrderStatusRefunded:
+		return status, nil
+	}
+	return "", NewValidationError(fmt.Sprintf("unknown order status %q", s))
+}
+
+// CanTransitionTo reports whether an order may move from s to next.
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ReleasesStock reports whether moving from one state to another returns the
+// order's items to stock: they are released when the order is cancelled, or
+// refunded before it was shipped.
+func ReleasesStock(from, to OrderStatus) bool {
+	switch to {
+	case OrderStatusCancelled:
+		return true
+	case OrderStatusRefunded:
+		return from == OrderStatusPaid || from == OrderStatusFulfilled
+	}
+	return false
+}
+
+// OrderTransition records a status change of an order.
+type OrderTransition struct {
+	OrderID int         `json:"order_id"`
+	From    OrderStatus `json:"from"`
+	To      OrderStatus `json:"to"`
+	Actor   string      `json:"actor"`
+	Note    string      `json:"note,omitempty"`
+	At      time.Time   `json:"at"`
+}
+
+// Transition moves the order to a new state on behalf of actor and returns
+// the record of the change. Illegal transitions fail with a conflict error.
+func (o *Order) Transition(to OrderStatus, actor, note string, at time.Time) (OrderTransition, error) {
+	if !o.Status.CanTransitionTo(to) {
+		return OrderTransition{}, NewConflictError(fmt.Sprintf("cannot move an order from %s to %s", o.Status, to), nil)
+	}
+	t := OrderTransition{OrderID: o.ID, From: o.Status, To: to, Actor: actor, Note: note, At: at}
+	o.Status = to
+	o.UpdatedAt = at
+	return t, nil
+}
+
+// Order is a confirmed purchase. Its stock was reserved when it was placed.
+type Order struct {
+	ID        int         `json:"id"`
+	UserID    *int        `json:"user_id"`
+	Status    OrderStatus `json:"status"`
+	Items     []OrderItem `json:"items"`
+	Total     Money       `json:"total"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// OrderItem is a line of an order. Name and UnitPrice are copied from the
+// product, or the variant when VariantID is set, while its stock is reserved.
+type OrderItem struct {
+	ProductID int    `json:"product_id"`
+	VariantID int    `json:"variant_id,omitempty"`
+	Name      string `json:"name"`
+	UnitPrice Money  `json:"unit_price"`
+	Quantity  int    `json:"quantity"`
+}
+
+// Subtotal is the unit price times the quantity.
+func (i OrderItem) Subtotal() Money {
+	return i.UnitPrice.Mul(i.Quantity)
+}
+
+// MarshalJSON adds the computed subtotal to the item.
+func (i OrderItem) MarshalJSON() ([]byte, error) {
+	type item OrderItem
+	return json.Marshal(struct {
+		item
+		Subtotal Money `json:"subtotal"`
+	}{item(i), i.Subtotal()})
+}
+
+// OrderLine asks for quantity units of a product at checkout. With a
+// VariantID the units are taken from that variant's stock instead of the
+// product's.
+type OrderLine struct {
+	ProductID int `json:"product_id"`
+	VariantID int `json:"variant_id,omitempty"`
+	Quantity  int `json:"quantity"`
+}
+
+// NewOrder builds a pending order from checkout lines. Lines for the same
+// product and variant are merged and the items are sorted by product and
+// variant ID, so concurrent checkouts always lock rows in the same order.
+func NewOrder(userID *int, lines []OrderLine) (Order, error) {
+	if len(lines) == 0 {
+		return Order{}, NewValidationError("an order needs at least one item")
+	}
+	type lineKey struct{ productID, variantID int }
+	quantities := make(map[lineKey]int)
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			return Order{}, NewValidationError("quantity must be positive")
+		}
+		if line.VariantID < 0 {
+			return Order{}, NewValidationError("variant_id must be positive")
+		}
+		quantities[lineKey{line.ProductID, line.VariantID}] += line.Quantity
+	}
+
+	order := Order{UserID: userID, Status: OrderStatusPending}
+	for key, quantity := range quantities {
+		order.Items = append(order.Items, OrderItem{ProductID: key.productID, VariantID: key.variantID, Quantity: quantity})
+	}
+	sort.Slice(order.Items, func(i, j int) bool {
+		a, b := order.Items[i], order.Items[j]
+		return a.ProductID < b.ProductID || a.ProductID == b.ProductID && a.VariantID < b.VariantID
+	})
+	return order, nil
+}
+
+// UpdateTotal recomputes Total from the items, which must all share one currency.
+func (o *Order) UpdateTotal() error {
+	if len(o.Items) == 0 {
+		o.Total = Money{Currency: DefaultCurrency}
+		return nil
+	}
+	total := Money{Currency: o.Items[0].UnitPrice.Currency}
+	for _, item := range o.Items {
+		var err error
+		if total, err = total.Add(item.Subtotal()); err != nil {
+			return NewValidationError(fmt.Sprintf("an order cannot mix currencies: %s", ErrorMessage(err, "")))
+		}
+	}
+	o.Total = total
+	return nil
+}
+
+// OrderRepository is implemented by every order store.
+type OrderRepository interface {
+	// Place reserves stock for every item and stores the order atomically,
+	// filling in each item's name and unit price and the order total. If any
+	// product lacks stock it fails with a conflict error and reserves nothing.
+	Place(ctx context.Context, order *Order) error
+	FindByID(ctx context.Context, id int) (Order, error)
+	FindByUser(ctx context.Context, userID int) ([]Order, error)
+	// Transition moves an order to a new state, records the change and, when
+	// ReleasesStock says so, returns its items to stock, all atomically.
+	Transition(ctx context.Context, id int, to OrderStatus, actor, note string) (Order, error)
+	// History lists an order's transitions, oldest first.
+	History(ctx context.Context, id int) ([]OrderTransition, error)
+}
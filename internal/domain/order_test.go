@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOrderTransition(t *testing.T) {
+	tests := []struct {
+		from, to OrderStatus
+		allowed  bool
+		releases bool
+	}{
+		{OrderStatusPending, OrderStatusPaid, true, false},
+		{OrderStatusPending, OrderStatusCancelled, true, true},
+		{OrderStatusPending, OrderStatusShipped, false, false},
+		{OrderStatusPaid, OrderStatusFulfilled, true, false},
+		{OrderStatusPaid, OrderStatusCancelled, false, false},
+		{OrderStatusPaid, OrderStatusRefunded, true, true},
+		{OrderStatusFulfilled, OrderStatusShipped, true, false},
+		{OrderStatusShipped, OrderStatusDelivered, true, false},
+		{OrderStatusShipped, OrderStatusRefunded, true, false},
+		{OrderStatusDelivered, OrderStatusRefunded, true, false},
+		{OrderStatusDelivered, OrderStatusPending, false, false},
+		{OrderStatusCancelled, OrderStatusPaid, false, false},
+		{OrderStatusRefunded, OrderStatusRefunded, false, false},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.from)+"->"+string(tc.to), func(t *testing.T) {
+			order := Order{ID: 7, Status: tc.from}
+			at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+			transition, err := order.Transition(tc.to, "user:1", "note", at)
+			if !tc.allowed {
+				if !errors.Is(err, ErrConflict) {
+					t.Fatalf("expected a conflict, got %v", err)
+				}
+				if order.Status != tc.from {
+					t.Errorf("expected the status to stay %s, got %s", tc.from, order.Status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := OrderTransition{OrderID: 7, From: tc.from, To: tc.to, Actor: "user:1", Note: "note", At: at}
+			if transition != want || order.Status != tc.to {
+				t.Errorf("got %+v with status %s, want %+v", transition, order.Status, want)
+			}
+			if got := ReleasesStock(tc.from, tc.to); got != tc.releases {
+				t.Errorf("ReleasesStock = %v, want %v", got, tc.releases)
+			}
+		})
+	}
+}
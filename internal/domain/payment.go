@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// PaymentStatus is the state of a payment at its provider.
+type PaymentStatus string
+
+// Payment states. An authorized payment is either captured, which makes its
+// order paid, or voided. A captured payment can later be refunded.
+const (
+	PaymentStatusAuthorized PaymentStatus = "authorized"
+	PaymentStatusCaptured   PaymentStatus = "captured"
+	PaymentStatusVoided     PaymentStatus = "voided"
+	PaymentStatusRefunded   PaymentStatus = "refunded"
+	PaymentStatusFailed     PaymentStatus = "failed"
+)
+
+// paymentTransitions lists the states each payment state may move to.
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentStatusAuthorized: {PaymentStatusCaptured, PaymentStatusVoided, PaymentStatusFailed},
+	PaymentStatusCaptured:   {PaymentStatusRefunded},
+}
+
+// CanTransitionTo reports whether a payment may move from s to next.
+func (s PaymentStatus) CanTransitionTo(next PaymentStatus) bool {
+	for _, allowed := range paymentTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckPaymentTransition returns a conflict error if a payment may not move
+// from one state to the other.
+func CheckPaymentTransition(from, to PaymentStatus) error {
+	if !from.CanTransitionTo(to) {
+		return NewConflictError(fmt.Sprintf("cannot move a payment from %s to %s", from, to), nil)
+	}
+	return nil
+}
+
+// SettlesOrder returns the state an order moves to when one of its payments
+// reaches s: a capture makes it paid and a refund refunds it.
+func (s PaymentStatus) SettlesOrder() (OrderStatus, bool) {
+	switch s {
+	case PaymentStatusCaptured:
+		return OrderStatusPaid, true
+	case PaymentStatusRefunded:
+		return OrderStatusRefunded, true
+	}
+	return "", false
+}
+
+// Payment is a charge for an order at a payment provider.
+type Payment struct {
+	ID            int           `json:"id"`
+	OrderID       int           `json:"order_id"`
+	Provider      string        `json:"provider"`
+	ProviderRef   string        `json:"provider_ref"`
+	Status        PaymentStatus `json:"status"`
+	Amount        Money         `json:"amount"`
+	FailureReason string        `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// PaymentResult is a provider's answer to a payment operation. Declined
+// operations are reported here rather than as errors, which are reserved for
+// failures to reach the provider.
+type PaymentResult struct {
+	ProviderRef string
+	Approved    bool
+	Reason      string
+}
+
+// PaymentProvider is implemented by every payment gateway integration.
+type PaymentProvider interface {
+	// Name identifies the provider in stored payments.
+	Name() string
+	// Authorize reserves amount on the payment source, e.g. a card token.
+	Authorize(ctx context.Context, orderID int, amount Money, source string) (PaymentResult, error)
+	// Capture collects a previously authorized amount.
+	Capture(ctx context.Context, ref string, amount Money) (PaymentResult, error)
+	// Void releases an authorization that was not captured.
+	Void(ctx context.Context, ref string) (PaymentResult, error)
+	// Refund returns a captured amount to the customer.
+	Refund(ctx context.Context, ref string, amount Money) (PaymentResult, error)
+}
+
+// PaymentRepository is implemented by every payment store.
+type PaymentRepository interface {
+	Save(ctx context.Context, payment *Payment) error
+	FindByID(ctx context.Context, id int) (Payment, error)
+	FindByProviderRef(ctx context.Context, provider, ref string) (Payment, error)
+	FindByOrder(ctx context.Context, orderID int) ([]Payment, error)
+	// UpdateStatus moves a payment from one state to another. It fails with a
+	// conflict error if the payment is no longer in the from state. When the
+	// new state settles the order (see PaymentStatus.SettlesOrder), the order
+	// is transitioned in the same transaction; if it cannot follow, neither
+	// changes.
+	UpdateStatus(ctx context.Context, id int, from, to PaymentStatus, reason string) (Payment, error)
+}
@@ -1,20 +1,160 @@
 package domain
 
-import _ "database/sql"
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
 
 // Product defines the structure for a product item.
 type Product struct {
-	ID          int     `json:"id"`
-	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
-	Amount      int     `json:"amount"`
-	Description string  `json:"description"`
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Price       Money  `json:"price"`
+	Amount      int    `json:"amount"`
+	Description string `json:"description"`
+	// SKU is the optional stock keeping unit used by warehouse integrations.
+	SKU string `json:"sku,omitempty"`
+	// Slug addresses the product in URLs. It is generated from Name when a
+	// product is created without one.
+	Slug string `json:"slug,omitempty"`
+	// Version increases with every change to the product, including its stock
+	// and variants. It backs the ETag of product responses.
+	Version int `json:"version,omitempty"`
+	// DeletedAt is set while the product is in the trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// VariantSummary aggregates the product's variants in read responses. It
+	// is nil for products without variants and ignored on writes.
+	VariantSummary *VariantSummary `json:"variant_summary,omitempty"`
 }
 
+// Validate checks the invariants every stored product must satisfy.
+func (p *Product) Validate() error {
+	if p.Name == "" || p.Price.IsZero() || p.Amount < 0 {
+		return NewValidationError("Invalid product data: name, price, and amount are required and must be valid")
+	}
+	if len(p.SKU) > maxSKULength || strings.IndexFunc(p.SKU, unicode.IsSpace) != -1 {
+		return NewValidationError(fmt.Sprintf("Invalid product data: sku must be at most %d characters without spaces", maxSKULength))
+	}
+	if p.Slug != "" && !validSlug(p.Slug) {
+		return NewValidationError("Invalid product data: slug may only contain lowercase letters, digits and single hyphens")
+	}
+	return p.Price.Validate()
+}
+
+// maxSKULength bounds SKUs to what label printers and warehouse systems accept.
+const maxSKULength = 64
+
+// Fields products can be sorted by.
+const (
+	ProductSortID     = "id"
+	ProductSortName   = "name"
+	ProductSortPrice  = "price"
+	ProductSortAmount = "amount"
+)
+
+// ProductSort orders the products returned by FindAll. Ties are always broken
+// by ID in the same direction, so the order is stable. The zero value sorts
+// by ID ascending.
+type ProductSort struct {
+	Field      string
+	Descending bool
+}
+
+// ParseProductSort validates a sort field and order ("asc" or "desc") taken
+// from user input. Empty values select the defaults.
+func ParseProductSort(field, order string) (ProductSort, error) {
+	switch field {
+	case "":
+		field = ProductSortID
+	case ProductSortID, ProductSortName, ProductSortPrice, ProductSortAmount:
+	default:
+		return ProductSort{}, NewValidationError(fmt.Sprintf("Invalid sort field %q: must be one of id, name, price, amount", field))
+	}
+
+	switch order {
+	case "", "asc":
+		return ProductSort{Field: field}, nil
+	case "desc":
+		return ProductSort{Field: field, Descending: true}, nil
+	default:
+		return ProductSort{}, NewValidationError(fmt.Sprintf("Invalid sort order %q: must be asc or desc", order))
+	}
+}
+
+// ProductFilter narrows and orders the products returned by FindAll. The
+// zero value matches every product, ordered by ID.
+type ProductFilter struct {
+	// CategoryIDs keeps only products assigned to at least one of these categories.
+	CategoryIDs []int
+	// Name keeps products whose name contains this text, case-insensitively.
+	Name string
+	// MinPrice and MaxPrice bound the price, inclusively.
+	MinPrice *Money
+	MaxPrice *Money
+	// InStock keeps only products with an amount greater than zero.
+	InStock bool
+	// Deleted selects the products in the trash instead of the live ones.
+	Deleted bool
+	Sort    ProductSort
+}
+
+// ProductPage is one page of a cursor-paginated product listing.
+type ProductPage struct {
+	Products []Product
+	// HasNext and HasPrev report whether rows exist after the last and before
+	// the first product of the page.
+	HasNext bool
+	HasPrev bool
+}
+
+// ProductRepository is implemented by every product store. Methods report
+// failures with the error kinds declared in errors.go.
 type ProductRepository interface {
-	Save(product *Product) error
-	FindAll(page, limit int) ([]Product, int, error)
-	FindByID(id int) (Product, error)
-	Update(product *Product) error
-	Delete(id int) error
+	// Save and Update fail with a conflict error when the SKU or slug is
+	// taken. A product saved without a slug gets one generated from its name,
+	// suffixed as needed to be unique; an update without a slug keeps the
+	// current one. Both set product.Version to the stored version.
+	Save(ctx context.Context, product *Product) error
+	FindAll(ctx context.Context, filter ProductFilter, page, limit int) ([]Product, int, error)
+	// FindPage returns up to limit products following (or, with cursor.Before,
+	// preceding) the cursor; a nil cursor starts at the beginning.
+	FindPage(ctx context.Context, filter ProductFilter, cursor *ProductCursor, limit int) (ProductPage, error)
+	Count(ctx context.Context, filter ProductFilter) (int, error)
+	// Stream calls fn with every product matching filter, in the filter's
+	// order, without holding them all in memory. It stops at the first error
+	// fn returns and returns it.
+	Stream(ctx context.Context, filter ProductFilter, fn func(Product) error) error
+	FindByID(ctx context.Context, id int) (Product, error)
+	FindBySKU(ctx context.Context, sku string) (Product, error)
+	FindBySlug(ctx context.Context, slug string) (Product, error)
+	// Search returns one page of the products matching a full-text query,
+	// best match first, and the total number of matches.
+	Search(ctx context.Context, query string, page, limit int) ([]ProductSearchHit, int, error)
+	// Suggest returns up to limit products whose name starts with, or nearly
+	// matches, prefix: names starting with it first, then the closest matches.
+	Suggest(ctx context.Context, prefix string, limit int) ([]ProductSuggestion, error)
+	// Update and Delete only apply when the stored version equals the
+	// expected one (product.Version for Update) and fail with a precondition
+	// error otherwise. An expected version of zero matches any version.
+	// Delete moves the product to the trash: every other method except
+	// FindAll with ProductFilter.Deleted treats it as gone, and its SKU and
+	// slug are free for other products to take.
+	Update(ctx context.Context, product *Product) error
+	Delete(ctx context.Context, id, expectedVersion int) error
+	// Restore takes a product out of the trash. It fails with a conflict
+	// error when the product is not deleted, or when a live product has
+	// taken its SKU or slug meanwhile.
+	Restore(ctx context.Context, id int) (Product, error)
+	// PurgeDeleted permanently removes the products deleted before the
+	// given time and returns how many there were.
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error)
+	// Bulk applies operations in order and returns one result per operation.
+	// With atomic set they run in a single transaction that is rolled back
+	// when any of them fails; otherwise every operation that can be applied
+	// is. Operations must already be validated. The error is only set when
+	// the batch as a whole could not be processed.
+	Bulk(ctx context.Context, operations []BulkProductOperation, atomic bool) ([]BulkProductResult, error)
 }
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"html"
+	"strings"
+)
+
+// ProductSearchHit is one ranked result of a full-text product search.
+type ProductSearchHit struct {
+	Product
+	// Rank orders the hits; it is only meaningful relative to the other hits
+	// of the same search.
+	Rank float64 `json:"rank"`
+	// Snippet is an HTML fragment of the matching text with the matched words
+	// wrapped in <mark> elements. Everything else in it is escaped.
+	Snippet string `json:"snippet"`
+}
+
+// Markers delimiting matched words in raw snippets produced by a store.
+// Stores strip them from the product text first, so they stay unambiguous.
+const (
+	SnippetStart = "\x02"
+	SnippetStop  = "\x03"
+)
+
+// snippetMarkup turns the markers into <mark> tags once the text is escaped.
+var snippetMarkup = strings.NewReplacer(SnippetStart, "<mark>", SnippetStop, "</mark>")
+
+// FormatSnippet escapes a raw snippet delimited with SnippetStart and
+// SnippetStop and turns the delimiters into <mark> tags.
+func FormatSnippet(raw string) string {
+	return snippetMarkup.Replace(html.EscapeString(raw))
+}
+
+// ProductSuggestion is a name completion for search-as-you-type. It carries
+// just enough to show the name and link to the product.
+type ProductSuggestion struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
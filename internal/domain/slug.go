@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxSlugLength caps generated slugs; collision suffixes may extend them a little.
+const maxSlugLength = 80
+
+// Slugify turns a name into a lowercase, hyphen-separated URL slug, dropping
+// accents: "Café Especial 500g" becomes "cafe-especial-500g". Names without
+// any letter or digit yield "product".
+func Slugify(name string) string {
+	return slugify(name, maxSlugLength)
+}
+
+func slugify(name string, limit int) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range norm.NFD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Combining accent split off by NFD.
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			hyphen = true
+		}
+		if b.Len() >= limit {
+			break
+		}
+	}
+	slug := strings.TrimRight(b.String(), "-")
+	if slug == "" {
+		return "product"
+	}
+	return slug
+}
+
+// SlugCandidate returns the n-th slug to try for base when earlier ones are
+// taken: base itself, then base-2, base-3 and so on.
+func SlugCandidate(base string, n int) string {
+	if n <= 1 {
+		return base
+	}
+	return base + "-" + strconv.Itoa(n)
+}
+
+// validSlug reports whether slug is in the canonical form Slugify produces,
+// allowing for a collision suffix.
+func validSlug(slug string) bool {
+	const limit = maxSlugLength + 10
+	return slug != "" && len(slug) <= limit && slugify(slug, limit) == slug
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Café Especial 500g", "cafe-especial-500g"},
+		{"  Caneca -- Térmica!  ", "caneca-termica"},
+		{"Maçã", "maca"},
+		{"日本", "product"},
+		{strings.Repeat("a", 100), strings.Repeat("a", 80)},
+	}
+	for _, tt := range tests {
+		if got := Slugify(tt.name); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if got := SlugCandidate("mug", 1); got != "mug" {
+		t.Errorf("expected the first candidate to be the base, got %q", got)
+	}
+	if got := SlugCandidate("mug", 3); got != "mug-3" {
+		t.Errorf("expected mug-3, got %q", got)
+	}
+}
+
+func TestProductValidate_SKUAndSlug(t *testing.T) {
+	tests := []struct {
+		sku, slug string
+		wantErr   bool
+	}{
+		{"MUG-001", "blue-mug", false},
+		{"", "", false},
+		{"MUG 001", "", true},
+		{strings.Repeat("X", 65), "", true},
+		{"", "Blue-Mug", true},
+		{"", "blue--mug", true},
+		{"", "-blue", true},
+	}
+	for _, tt := range tests {
+		p := Product{Name: "Blue Mug", Price: NewMoney(1990, "BRL"), SKU: tt.sku, Slug: tt.slug}
+		err := p.Validate()
+		if tt.wantErr && !errors.Is(err, ErrValidation) {
+			t.Errorf("sku %q slug %q: expected a validation error, got %v", tt.sku, tt.slug, err)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("sku %q slug %q: unexpected error %v", tt.sku, tt.slug, err)
+		}
+	}
+}
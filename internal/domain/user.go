@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"context"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// User is an account that can authenticate against the API.
+type User struct {
+	ID           int       `json:"id"`
+	Email        string    `json:"email"`
+	Name         string    `json:"name"`
+	PasswordHash string    `json:"-"`
+	Roles        []string  `json:"roles"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// NormalizeEmail lower-cases and trims an address so lookups are case-insensitive.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Validate checks the invariants every stored user must satisfy.
+func (u *User) Validate() error {
+	if _, err := mail.ParseAddress(u.Email); err != nil || u.Email != NormalizeEmail(u.Email) {
+		return NewValidationError("Invalid user data: a valid email is required")
+	}
+	if strings.TrimSpace(u.Name) == "" {
+		return NewValidationError("Invalid user data: name is required")
+	}
+	return nil
+}
+
+// UserRepository is implemented by every user store.
+type UserRepository interface {
+	// Save fails with a conflict error if the email is already registered.
+	Save(ctx context.Context, user *User) error
+	FindByID(ctx context.Context, id int) (User, error)
+	FindByEmail(ctx context.Context, email string) (User, error)
+}
+
+// RefreshToken records an issued refresh token. Tokens obtained from one
+// another through rotation share a FamilyID, so reuse of a rotated token can
+// revoke the whole chain.
+type RefreshToken struct {
+	ID        string
+	UserID    int
+	FamilyID  string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// RefreshTokenRepository is implemented by every refresh token store.
+type RefreshTokenRepository interface {
+	Save(ctx context.Context, token *RefreshToken) error
+	FindByID(ctx context.Context, id string) (RefreshToken, error)
+	// Revoke marks an active token as used. It fails with a conflict error if
+	// the token was already revoked, which signals reuse.
+	Revoke(ctx context.Context, id string) error
+	RevokeFamily(ctx context.Context, familyID string) error
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"context"
+	"strings"
+)
+
+// Variant is a purchasable version of a product, e.g. one size and color of
+// a T-shirt, with its own SKU and stock. Price overrides the product price
+// when set and is always in the product's currency.
+type Variant struct {
+	ID        int               `json:"id"`
+	ProductID int               `json:"product_id"`
+	SKU       string            `json:"sku"`
+	Options   map[string]string `json:"options"`
+	Price     *Money            `json:"price,omitempty"`
+	Amount    int               `json:"amount"`
+}
+
+// Validate checks the invariants every stored variant of product must satisfy.
+func (v *Variant) Validate(product Product) error {
+	if strings.TrimSpace(v.SKU) == "" || v.Amount < 0 {
+		return NewValidationError("Invalid variant data: sku is required and amount must not be negative")
+	}
+	for name := range v.Options {
+		if strings.TrimSpace(name) == "" {
+			return NewValidationError("Invalid variant data: option names must not be empty")
+		}
+	}
+	if v.Price != nil {
+		if v.Price.Currency != product.Price.Currency {
+			return NewValidationError("Invalid variant data: price must be in the product's currency")
+		}
+		return v.Price.Validate()
+	}
+	return nil
+}
+
+// EffectivePrice is the variant's price override, or the product price.
+func (v *Variant) EffectivePrice(product Product) Money {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return product.Price
+}
+
+// VariantSummary aggregates the variants of a product for listings.
+type VariantSummary struct {
+	Count      int        `json:"count"`
+	TotalStock int        `json:"total_stock"`
+	PriceRange PriceRange `json:"price_range"`
+}
+
+// PriceRange is the lowest and highest price of a product's variants.
+type PriceRange struct {
+	Min Money `json:"min"`
+	Max Money `json:"max"`
+}
+
+// SummarizeVariants aggregates the variants of product. It returns nil when
+// there are none.
+func SummarizeVariants(product Product, variants []Variant) *VariantSummary {
+	if len(variants) == 0 {
+		return nil
+	}
+	summary := &VariantSummary{}
+	for i, v := range variants {
+		price := v.EffectivePrice(product)
+		if i == 0 || price.Amount < summary.PriceRange.Min.Amount {
+			summary.PriceRange.Min = price
+		}
+		if i == 0 || price.Amount > summary.PriceRange.Max.Amount {
+			summary.PriceRange.Max = price
+		}
+		summary.Count++
+		summary.TotalStock += v.Amount
+	}
+	return summary
+}
+
+// VariantRepository is implemented by every variant store.
+type VariantRepository interface {
+	// Save fails with a conflict error if the SKU is already taken.
+	Save(ctx context.Context, variant *Variant) error
+	FindByProduct(ctx context.Context, productID int) ([]Variant, error)
+	FindByID(ctx context.Context, productID, id int) (Variant, error)
+	Update(ctx context.Context, variant *Variant) error
+	Delete(ctx context.Context, productID, id int) error
+	// Summaries aggregates the variants of each of the given products.
+	// Products without variants are left out of the result.
+	Summaries(ctx context.Context, productIDs []int) (map[int]VariantSummary, error)
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVariantValidate(t *testing.T) {
+	shirt := Product{ID: 1, Name: "Shirt", Price: NewMoney(4990, "BRL"), Amount: 0}
+	usd := NewMoney(1000, "USD")
+
+	tests := []struct {
+		name    string
+		variant Variant
+		wantErr bool
+	}{
+		{"valid", Variant{SKU: "SHIRT-M-RED", Options: map[string]string{"size": "M", "color": "red"}, Amount: 3}, false},
+		{"missing sku", Variant{SKU: " ", Amount: 3}, true},
+		{"negative stock", Variant{SKU: "SHIRT-M", Amount: -1}, true},
+		{"empty option name", Variant{SKU: "SHIRT-M", Options: map[string]string{"": "M"}}, true},
+		{"foreign currency", Variant{SKU: "SHIRT-M", Price: &usd}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.variant.Validate(shirt)
+			if tt.wantErr && !errors.Is(err, ErrValidation) {
+				t.Errorf("expected a validation error, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSummarizeVariants(t *testing.T) {
+	shirt := Product{ID: 1, Name: "Shirt", Price: NewMoney(4990, "BRL")}
+	if summary := SummarizeVariants(shirt, nil); summary != nil {
+		t.Errorf("expected no summary without variants, got %+v", summary)
+	}
+
+	cheap, dear := NewMoney(3990, "BRL"), NewMoney(5990, "BRL")
+	summary := SummarizeVariants(shirt, []Variant{
+		{SKU: "S", Amount: 2, Price: &cheap},
+		{SKU: "M", Amount: 5},
+		{SKU: "XL", Amount: 1, Price: &dear},
+	})
+	if summary == nil {
+		t.Fatal("expected a summary")
+	}
+	if summary.Count != 3 || summary.TotalStock != 8 {
+		t.Errorf("expected 3 variants with 8 in stock, got %+v", summary)
+	}
+	if summary.PriceRange.Min != cheap || summary.PriceRange.Max != dear {
+		t.Errorf("expected price range 39.90-59.90, got %s-%s", summary.PriceRange.Min, summary.PriceRange.Max)
+	}
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"math"
+	"net/http"
+
+	"e-commerce.com/internal/domain"
+)
+
+// AuditHandler serves the audit log of catalog changes.
+type AuditHandler struct {
+	repo domain.AuditRepository
+}
+
+// NewAuditHandler creates a new instance of AuditHandler.
+func NewAuditHandler(repo domain.AuditRepository) *AuditHandler {
+	return &AuditHandler{repo: repo}
+}
+
+// AuditResponse is one page of audit log entries.
+type AuditResponse struct {
+	Data        []domain.AuditEntry `json:"data"`
+	TotalPages  int                 `json:"total_pages"`
+	CurrentPage int                 `json:"current_page"`
+}
+
+// ListAudit godoc
+// @Summary      List audit log entries
+// @Description  Returns the recorded changes to an entity, newest first. Each entry names the actor and request that made the change, with the changed fields before and after it. Without an id, the changes to every entity of the kind are listed.
+// @Tags         audit
+// @Produce      json
+// @Param        entity  query     string  true   "Entity kind, e.g. product"
+// @Param        id      query     string  false  "Entity ID"
+// @Param        page    query     int     false  "Page number" default(1)
+// @Param        limit   query     int     false  "Items per page" default(50)
+// @Success      200     {object}  AuditResponse
+// @Failure      400     {object}  ErrorResponse
+// @Failure      401     {object}  ErrorResponse
+// @Failure      403     {object}  ErrorResponse
+// @Failure      500     {object}  ErrorResponse
+// @Router       /audit [get]
+func (h *AuditHandler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	entity := r.URL.Query().Get("entity")
+	if entity == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing entity: entity is required")
+		return
+	}
+	page, limit := pageParams(r)
+
+	entries, total, err := h.repo.List(r.Context(), entity, r.URL.Query().Get("id"), page, limit)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve audit log")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, AuditResponse{
+		Data:        entries,
+		TotalPages:  int(math.Ceil(float64(total) / float64(limit))),
+		CurrentPage: page,
+	})
+}
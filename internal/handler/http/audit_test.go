@@ -0,0 +1,53 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"e-commerce.com/internal/domain"
+	"e-commerce.com/internal/storage"
+)
+
+func TestAuditHandler_ListAudit(t *testing.T) {
+	repo := &storage.MockAuditRepository{Entries: []domain.AuditEntry{
+		{ID: 1, Entity: domain.AuditEntityProduct, EntityID: "1", Action: domain.AuditCreate, Actor: "user:7", After: json.RawMessage(`{"name":"Mouse"}`)},
+		{ID: 2, Entity: domain.AuditEntityProduct, EntityID: "2", Action: domain.AuditCreate, Actor: "user:7"},
+		{ID: 3, Entity: domain.AuditEntityProduct, EntityID: "1", Action: domain.AuditUpdate, Actor: "user:8", RequestID: "host/abc-000001",
+			Before: json.RawMessage(`{"amount":9}`), After: json.RawMessage(`{"amount":7}`)},
+	}}
+	h := NewAuditHandler(repo)
+	list := func(target string) (*httptest.ResponseRecorder, AuditResponse) {
+		rr := httptest.NewRecorder()
+		h.ListAudit(rr, httptest.NewRequest("GET", target, nil))
+		var resp AuditResponse
+		if rr.Code == http.StatusOK {
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+		}
+		return rr, resp
+	}
+
+	rr, resp := list("/audit?entity=product&id=1")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if len(resp.Data) != 2 || resp.Data[0].ID != 3 || resp.Data[1].ID != 1 {
+		t.Fatalf("expected the product's entries newest first, got %+v", resp.Data)
+	}
+	if got := resp.Data[0]; got.Actor != "user:8" || got.RequestID != "host/abc-000001" || string(got.Before) != `{"amount":9}` {
+		t.Errorf("expected the update with its actor, request and diff, got %+v", got)
+	}
+
+	if _, resp := list("/audit?entity=product&limit=2&page=2"); len(resp.Data) != 1 || resp.TotalPages != 2 || resp.CurrentPage != 2 {
+		t.Errorf("expected the last page of every product entry, got %+v", resp)
+	}
+	if _, resp := list("/audit?entity=category"); resp.Data == nil || len(resp.Data) != 0 {
+		t.Errorf("expected an empty list for an entity without entries, got %+v", resp.Data)
+	}
+	if rr, _ := list("/audit?id=1"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without an entity, got %d", rr.Code)
+	}
+}
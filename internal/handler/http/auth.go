@@ -0,0 +1,107 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"e-commerce.com/internal/auth"
+	"e-commerce.com/internal/domain"
+)
+
+// Authenticate rejects requests without a valid bearer token and stores the
+// token's claims on the request context for the handlers downstream.
+func Authenticate(verifier *auth.Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer`)
+				respondWithDomainError(w, domain.NewUnauthorizedError("missing bearer token"), "Unauthorized")
+				return
+			}
+
+			claims, err := verifier.Verify(token)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				respondWithDomainError(w, err, "Unauthorized")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithClaims(r.Context(), claims)))
+		})
+	}
+}
+
+// OptionalAuthenticate is Authenticate for routes that also serve anonymous
+// callers: requests without an Authorization header pass through unchanged,
+// but a header with an invalid token is still rejected.
+func OptionalAuthenticate(verifier *auth.Verifier) func(http.Handler) http.Handler {
+	authenticate := Authenticate(verifier)
+	return func(next http.Handler) http.Handler {
+		withClaims := authenticate(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			withClaims.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireRole only lets through callers holding at least one of roles. It
+// must run after Authenticate.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := auth.ClaimsFromContext(r.Context())
+			if !ok {
+				respondWithDomainError(w, domain.NewUnauthorizedError("authentication required"), "Unauthorized")
+				return
+			}
+			if !claims.HasAnyRole(roles...) {
+				respondWithDomainError(w, domain.NewForbiddenError("insufficient permissions"), "Forbidden")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// currentUserID returns the ID of the registered user making the request. It
+// writes a 401 itself when there is none.
+func currentUserID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		respondWithDomainError(w, domain.NewUnauthorizedError("authentication required"), "Unauthorized")
+		return 0, false
+	}
+	id, ok := claims.UserID()
+	if !ok {
+		respondWithDomainError(w, domain.NewUnauthorizedError("token does not belong to a registered user"), "Unauthorized")
+		return 0, false
+	}
+	return id, true
+}
+
+// ownsUserResource reports whether the authenticated caller is the given
+// user or an admin.
+func ownsUserResource(r *http.Request, userID int) bool {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		return false
+	}
+	if id, ok := claims.UserID(); ok && id == userID {
+		return true
+	}
+	return claims.HasAnyRole(auth.RoleAdmin)
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
@@ -0,0 +1,78 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"e-commerce.com/internal/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func signTestToken(t *testing.T, secret string, claims auth.Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestAuthenticateAndRequireRole(t *testing.T) {
+	verifier := auth.NewVerifier(auth.Config{HMACSecret: []byte("secret"), Issuer: "shop", Audience: "api"})
+	handler := Authenticate(verifier)(RequireRole(auth.RoleAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := auth.ClaimsFromContext(r.Context())
+		if !ok || claims.Subject != "alice" {
+			t.Errorf("expected claims for alice on the context, got %+v", claims)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	valid := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "alice",
+			Issuer:    "shop",
+			Audience:  jwt.ClaimStrings{"api"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		Roles: []string{auth.RoleAdmin},
+	}
+	customer := valid
+	customer.Roles = []string{auth.RoleCustomer}
+	expired := valid
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	wrongAudience := valid
+	wrongAudience.Audience = jwt.ClaimStrings{"other"}
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+	}{
+		{"valid admin", "Bearer " + signTestToken(t, "secret", valid), http.StatusNoContent},
+		{"missing header", "", http.StatusUnauthorized},
+		{"wrong scheme", "Basic abc", http.StatusUnauthorized},
+		{"wrong secret", "Bearer " + signTestToken(t, "other", valid), http.StatusUnauthorized},
+		{"expired", "Bearer " + signTestToken(t, "secret", expired), http.StatusUnauthorized},
+		{"wrong audience", "Bearer " + signTestToken(t, "secret", wrongAudience), http.StatusUnauthorized},
+		{"missing role", "Bearer " + signTestToken(t, "secret", customer), http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("DELETE", "/products/1", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("got status %v, want %v (%s)", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+		})
+	}
+}
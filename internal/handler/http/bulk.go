@@ -0,0 +1,124 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"e-commerce.com/internal/domain"
+)
+
+// Modes of a bulk request.
+const (
+	BulkModeAtomic     = "atomic"
+	BulkModeBestEffort = "best_effort"
+)
+
+// maxBulkBody bounds the size of a bulk request body.
+const maxBulkBody = 8 << 20
+
+// BulkRequest is the payload of POST /products/bulk. Mode defaults to atomic.
+type BulkRequest struct {
+	Mode       string                        `json:"mode" enums:"atomic,best_effort"`
+	Operations []domain.BulkProductOperation `json:"operations"`
+}
+
+// BulkResult reports the outcome of the operation at Index.
+type BulkResult struct {
+	Index   int             `json:"index"`
+	Op      string          `json:"op"`
+	Status  string          `json:"status" enums:"created,updated,deleted,failed,rolled_back,skipped"`
+	Product *domain.Product `json:"product,omitempty"`
+	Error   *ErrorResponse  `json:"error,omitempty"`
+}
+
+// BulkResponse is the per-operation report of a bulk request.
+type BulkResponse struct {
+	Mode      string       `json:"mode"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Results   []BulkResult `json:"results"`
+}
+
+// BulkProducts godoc
+// @Summary      Create, update and delete products in bulk
+// @Description  Applies up to 1000 upsert and delete operations in order. An upsert updates the product with the given id, or the product with the given SKU when the id is omitted, and creates a product otherwise; its version, like a delete's, must match when set. In atomic mode (the default) all operations run in one transaction and none is applied if any fails, which is answered with 422. In best_effort mode every valid operation is applied. The response reports the outcome of each operation.
+// @Tags         products
+// @Accept       json
+// @Produce      json
+// @Param        request  body      BulkRequest  true  "Operations"
+// @Success      200      {object}  BulkResponse
+// @Failure      400      {object}  ErrorResponse
+// @Failure      422      {object}  BulkResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /products/bulk [post]
+func (h *ProductHandler) BulkProducts(w http.ResponseWriter, r *http.Request) {
+	var req BulkRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBulkBody)).Decode(&req); err != nil {
+		respondWithDecodeError(w, err)
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = BulkModeAtomic
+	}
+	if req.Mode != BulkModeAtomic && req.Mode != BulkModeBestEffort {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid mode %q: must be atomic or best_effort", req.Mode))
+		return
+	}
+	if len(req.Operations) == 0 || len(req.Operations) > domain.MaxBulkOperations {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid operations: between 1 and %d are required", domain.MaxBulkOperations))
+		return
+	}
+	atomic := req.Mode == BulkModeAtomic
+
+	// Invalid operations never reach the repository; in atomic mode a single
+	// one cancels the whole batch.
+	results := make([]domain.BulkProductResult, len(req.Operations))
+	var (
+		valid   []domain.BulkProductOperation
+		indexes []int
+	)
+	for i := range req.Operations {
+		if err := req.Operations[i].Validate(); err != nil {
+			results[i] = domain.BulkProductResult{Status: domain.BulkFailed, Err: err}
+			continue
+		}
+		valid = append(valid, req.Operations[i])
+		indexes = append(indexes, i)
+	}
+
+	if atomic && len(valid) < len(req.Operations) {
+		for _, i := range indexes {
+			results[i] = domain.BulkProductResult{Status: domain.BulkSkipped}
+		}
+	} else if len(valid) > 0 {
+		applied, err := h.repo.Bulk(r.Context(), valid, atomic)
+		if err != nil {
+			respondWithDomainError(w, err, "Failed to apply bulk operations")
+			return
+		}
+		for j, result := range applied {
+			results[indexes[j]] = result
+		}
+	}
+
+	resp := BulkResponse{Mode: req.Mode, Results: make([]BulkResult, len(results))}
+	for i, result := range results {
+		item := BulkResult{Index: i, Op: req.Operations[i].Op, Status: result.Status, Product: result.Product}
+		switch result.Status {
+		case domain.BulkFailed:
+			resp.Failed++
+			_, body := domainErrorResponse(result.Err, "Failed to apply operation")
+			item.Error = &body
+		case domain.BulkCreated, domain.BulkUpdated, domain.BulkDeleted:
+			resp.Succeeded++
+		}
+		resp.Results[i] = item
+	}
+
+	status := http.StatusOK
+	if atomic && resp.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	respondWithJSON(w, status, resp)
+}
@@ -0,0 +1,125 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"e-commerce.com/internal/domain"
+	"e-commerce.com/internal/storage"
+)
+
+func TestBulkProductsHandler(t *testing.T) {
+	newRepo := func() *storage.MockProductRepository {
+		return &storage.MockProductRepository{Products: []domain.Product{
+			{ID: 1, Name: "Mouse", Price: domain.NewMoney(1990, "USD"), Amount: 9, SKU: "MOUSE-1", Slug: "mouse", Version: 1},
+			{ID: 2, Name: "Cable", Price: domain.NewMoney(500, "USD"), Amount: 3, Slug: "cable", Version: 1},
+		}}
+	}
+	bulk := func(products *storage.MockProductRepository, body string) (int, BulkResponse) {
+		h := NewProductHandler(products, &storage.MockCategoryRepository{}, &storage.MockVariantRepository{})
+		rr := httptest.NewRecorder()
+		h.BulkProducts(rr, httptest.NewRequest("POST", "/products/bulk", strings.NewReader(body)))
+		var resp BulkResponse
+		if rr.Code == http.StatusOK || rr.Code == http.StatusUnprocessableEntity {
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+		}
+		return rr.Code, resp
+	}
+	statuses := func(resp BulkResponse) []string {
+		var got []string
+		for _, r := range resp.Results {
+			got = append(got, r.Status)
+		}
+		return got
+	}
+
+	operations := `[
+		{"op": "upsert", "product": {"name": "Keyboard", "price": {"amount": "49.90", "currency": "USD"}, "amount": 5}},
+		{"op": "upsert", "product": {"name": "Gaming Mouse", "price": {"amount": "24.90", "currency": "USD"}, "amount": 4, "sku": "MOUSE-1"}},
+		{"op": "delete", "id": 2, "version": 7},
+		{"op": "upsert", "product": {"name": "", "amount": 1}}
+	]`
+
+	t.Run("best effort applies what it can", func(t *testing.T) {
+		products := newRepo()
+		code, resp := bulk(products, `{"mode": "best_effort", "operations": `+operations+`}`)
+		if code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", code)
+		}
+		want := []string{domain.BulkCreated, domain.BulkUpdated, domain.BulkFailed, domain.BulkFailed}
+		if got := statuses(resp); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("expected statuses %v, got %v", want, got)
+		}
+		if resp.Succeeded != 2 || resp.Failed != 2 {
+			t.Errorf("expected 2 succeeded and 2 failed, got %d and %d", resp.Succeeded, resp.Failed)
+		}
+		if e := resp.Results[2].Error; e == nil || e.Code != "precondition_failed" {
+			t.Errorf("expected a precondition error for the stale delete, got %+v", e)
+		}
+		if e := resp.Results[3].Error; e == nil || e.Code != "validation_error" {
+			t.Errorf("expected a validation error for the invalid product, got %+v", e)
+		}
+		if len(products.Products) != 3 || products.Products[0].Name != "Gaming Mouse" {
+			t.Errorf("expected the mouse to be updated by SKU and the keyboard added, got %+v", products.Products)
+		}
+	})
+
+	t.Run("atomic rejects invalid operations up front", func(t *testing.T) {
+		products := newRepo()
+		code, resp := bulk(products, `{"operations": `+operations+`}`)
+		if code != http.StatusUnprocessableEntity {
+			t.Fatalf("expected 422, got %d", code)
+		}
+		want := []string{domain.BulkSkipped, domain.BulkSkipped, domain.BulkSkipped, domain.BulkFailed}
+		if got := statuses(resp); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("expected statuses %v, got %v", want, got)
+		}
+		if len(products.Products) != 2 || products.Products[0].Name != "Mouse" {
+			t.Errorf("expected no changes, got %+v", products.Products)
+		}
+	})
+
+	t.Run("atomic rolls back on failure", func(t *testing.T) {
+		products := newRepo()
+		code, resp := bulk(products, `{"mode": "atomic", "operations": [
+			{"op": "upsert", "product": {"name": "Keyboard", "price": {"amount": "49.90", "currency": "USD"}, "amount": 5}},
+			{"op": "delete", "id": 42},
+			{"op": "delete", "id": 2}
+		]}`)
+		if code != http.StatusUnprocessableEntity {
+			t.Fatalf("expected 422, got %d", code)
+		}
+		want := []string{domain.BulkRolledBack, domain.BulkFailed, domain.BulkSkipped}
+		if got := statuses(resp); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("expected statuses %v, got %v", want, got)
+		}
+		if len(products.Products) != 2 {
+			t.Errorf("expected the created product to be rolled back, got %+v", products.Products)
+		}
+	})
+
+	t.Run("atomic commits when everything succeeds", func(t *testing.T) {
+		products := newRepo()
+		code, resp := bulk(products, `{"operations": [
+			{"op": "upsert", "product": {"id": 2, "name": "Cable", "price": {"amount": "4.00", "currency": "USD"}, "amount": 3, "version": 1}},
+			{"op": "delete", "id": 1, "version": 1}
+		]}`)
+		if code != http.StatusOK || resp.Succeeded != 2 {
+			t.Fatalf("expected 200 with 2 successes, got %d: %+v", code, resp)
+		}
+		if products.Products[0].DeletedAt == nil || products.Products[1].Price != domain.NewMoney(400, "USD") {
+			t.Errorf("expected the cable to be repriced and the mouse deleted, got %+v", products.Products)
+		}
+	})
+
+	for _, body := range []string{`{"operations": []}`, `{"mode": "eventually", "operations": [{"op": "delete", "id": 1}]}`, `[`} {
+		if code, _ := bulk(newRepo(), body); code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", body, code)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"e-commerce.com/internal/auth"
+	"e-commerce.com/internal/domain"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CartHandler serves the /carts routes. Carts created by an authenticated
+// user belong to that user; anonymous carts can be used by anyone holding
+// their ID.
+type CartHandler struct {
+	carts    domain.CartRepository
+	products domain.ProductRepository
+}
+
+// NewCartHandler creates a new instance of CartHandler.
+func NewCartHandler(carts domain.CartRepository, products domain.ProductRepository) *CartHandler {
+	return &CartHandler{carts: carts, products: products}
+}
+
+// CartItemRequest is the body of the cart item routes. ProductID is only
+// read when adding an item.
+type CartItemRequest struct {
+	ProductID int `json:"product_id"`
+	Quantity  int `json:"quantity"`
+}
+
+// CreateCart godoc
+// @Summary      Create a cart
+// @Description  Creates an empty cart. With a bearer token the cart belongs to the user, and the user's existing cart is returned if there is one. Without one the cart is anonymous and its ID acts as the session token.
+// @Tags         carts
+// @Produce      json
+// @Success      200  {object}  domain.Cart
+// @Success      201  {object}  domain.Cart
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /carts [post]
+func (h *CartHandler) CreateCart(w http.ResponseWriter, r *http.Request) {
+	var cart domain.Cart
+	if claims, ok := auth.ClaimsFromContext(r.Context()); ok {
+		userID, ok := claims.UserID()
+		if !ok {
+			respondWithDomainError(w, domain.NewUnauthorizedError("token does not belong to a registered user"), "Unauthorized")
+			return
+		}
+		existing, err := h.carts.FindByUser(r.Context(), userID)
+		if err == nil {
+			respondWithJSON(w, http.StatusOK, existing)
+			return
+		}
+		if !errors.Is(err, domain.ErrNotFound) {
+			respondWithDomainError(w, err, "Failed to create cart")
+			return
+		}
+		cart.UserID = &userID
+	}
+
+	if err := h.carts.Create(r.Context(), &cart); err != nil {
+		respondWithDomainError(w, err, "Failed to create cart")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, cart)
+}
+
+// GetCart godoc
+// @Summary      Get a cart
+// @Description  Returns the cart with its items, subtotals and total.
+// @Tags         carts
+// @Produce      json
+// @Param        id   path      string  true  "Cart ID"
+// @Success      200  {object}  domain.Cart
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /carts/{id} [get]
+func (h *CartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
+	cart, ok := h.loadCart(w, r)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, cart)
+}
+
+// DeleteCart godoc
+// @Summary      Delete a cart
+// @Description  Deletes the cart and all of its items.
+// @Tags         carts
+// @Produce      json
+// @Param        id   path      string  true  "Cart ID"
+// @Success      200  {object}  map[string]string
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /carts/{id} [delete]
+func (h *CartHandler) DeleteCart(w http.ResponseWriter, r *http.Request) {
+	cart, ok := h.loadCart(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.carts.Delete(r.Context(), cart.ID); err != nil {
+		respondWithDomainError(w, err, "Failed to delete cart")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Cart deleted successfully"})
+}
+
+// AddItem godoc
+// @Summary      Add a product to a cart
+// @Description  Adds a product at its current price, or increases its quantity if it is already in the cart. The quantity may not exceed the product's stock.
+// @Tags         carts
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string           true  "Cart ID"
+// @Param        item  body      CartItemRequest  true  "Cart Item Payload"
+// @Success      200   {object}  domain.Cart
+// @Failure      400   {object}  ErrorResponse
+// @Failure      403   {object}  ErrorResponse
+// @Failure      404   {object}  ErrorResponse
+// @Failure      409   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Router       /carts/{id}/items [post]
+func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	cart, ok := h.loadCart(w, r)
+	if !ok {
+		return
+	}
+	var req CartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithDecodeError(w, err)
+		return
+	}
+
+	product, ok := h.findProduct(w, r, req.ProductID)
+	if !ok {
+		return
+	}
+	h.update(w, r, cart.ID, "Failed to add item", func(cart *domain.Cart) error {
+		return cart.AddItem(product, req.Quantity)
+	})
+}
+
+// UpdateItem godoc
+// @Summary      Change the quantity of a cart item
+// @Description  Sets the quantity of a product already in the cart. The quantity may not exceed the product's stock.
+// @Tags         carts
+// @Accept       json
+// @Produce      json
+// @Param        id         path      string           true  "Cart ID"
+// @Param        productID  path      int              true  "Product ID"
+// @Param        item       body      CartItemRequest  true  "Cart Item Payload"
+// @Success      200        {object}  domain.Cart
+// @Failure      400        {object}  ErrorResponse
+// @Failure      403        {object}  ErrorResponse
+// @Failure      404        {object}  ErrorResponse
+// @Failure      409        {object}  ErrorResponse
+// @Failure      500        {object}  ErrorResponse
+// @Router       /carts/{id}/items/{productID} [put]
+func (h *CartHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	cart, ok := h.loadCart(w, r)
+	if !ok {
+		return
+	}
+	productID, err := strconv.Atoi(chi.URLParam(r, "productID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+	var req CartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithDecodeError(w, err)
+		return
+	}
+
+	product, ok := h.findProduct(w, r, productID)
+	if !ok {
+		return
+	}
+	h.update(w, r, cart.ID, "Failed to update item", func(cart *domain.Cart) error {
+		return cart.UpdateItem(product, req.Quantity)
+	})
+}
+
+// RemoveItem godoc
+// @Summary      Remove a product from a cart
+// @Description  Removes the product's line from the cart.
+// @Tags         carts
+// @Produce      json
+// @Param        id         path      string  true  "Cart ID"
+// @Param        productID  path      int     true  "Product ID"
+// @Success      200        {object}  domain.Cart
+// @Failure      400        {object}  ErrorResponse
+// @Failure      403        {object}  ErrorResponse
+// @Failure      404        {object}  ErrorResponse
+// @Failure      500        {object}  ErrorResponse
+// @Router       /carts/{id}/items/{productID} [delete]
+func (h *CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	cart, ok := h.loadCart(w, r)
+	if !ok {
+		return
+	}
+	productID, err := strconv.Atoi(chi.URLParam(r, "productID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	h.update(w, r, cart.ID, "Failed to remove item", func(cart *domain.Cart) error {
+		return cart.RemoveItem(productID)
+	})
+}
+
+// loadCart fetches the cart named in the URL and checks that the caller may
+// use it. It writes the error response itself when it fails.
+func (h *CartHandler) loadCart(w http.ResponseWriter, r *http.Request) (domain.Cart, bool) {
+	cart, err := h.carts.FindByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve cart")
+		return domain.Cart{}, false
+	}
+	if cart.UserID != nil && !ownsUserResource(r, *cart.UserID) {
+		respondWithDomainError(w, domain.NewForbiddenError("cart belongs to another user"), "Forbidden")
+		return domain.Cart{}, false
+	}
+	return cart, true
+}
+
+func (h *CartHandler) findProduct(w http.ResponseWriter, r *http.Request, id int) (domain.Product, bool) {
+	product, err := h.products.FindByID(r.Context(), id)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve product")
+		return domain.Product{}, false
+	}
+	return product, true
+}
+
+// update applies change to the stored cart, so that concurrent requests on
+// the same cart do not overwrite each other's items.
+func (h *CartHandler) update(w http.ResponseWriter, r *http.Request, id, message string, change func(cart *domain.Cart) error) {
+	cart, err := h.carts.Update(r.Context(), id, change)
+	if err != nil {
+		respondWithDomainError(w, err, message)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, cart)
+}
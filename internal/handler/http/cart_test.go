@@ -0,0 +1,140 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"e-commerce.com/internal/auth"
+	"e-commerce.com/internal/domain"
+	"e-commerce.com/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newCartRouter(carts *storage.MockCartRepository, products *storage.MockProductRepository) http.Handler {
+	h := NewCartHandler(carts, products)
+	r := chi.NewRouter()
+	r.Use(OptionalAuthenticate(newTestVerifier()))
+	r.Post("/carts", h.CreateCart)
+	r.Get("/carts/{id}", h.GetCart)
+	r.Post("/carts/{id}/items", h.AddItem)
+	r.Put("/carts/{id}/items/{productID}", h.UpdateItem)
+	r.Delete("/carts/{id}/items/{productID}", h.RemoveItem)
+	return r
+}
+
+func cartRequest(t *testing.T, router http.Handler, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &payload)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func newTestVerifier() *auth.Verifier {
+	return auth.NewVerifier(auth.Config{HMACSecret: []byte("secret")})
+}
+
+func userToken(t *testing.T, userID string) string {
+	return signTestToken(t, "secret", auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		Roles: []string{auth.RoleCustomer},
+	})
+}
+
+func TestCartHandler_AnonymousCart(t *testing.T) {
+	products := &storage.MockProductRepository{Products: []domain.Product{
+		{ID: 1, Name: "Mug", Price: domain.NewMoney(1990, "BRL"), Amount: 2},
+	}}
+	router := newCartRouter(&storage.MockCartRepository{}, products)
+
+	rr := cartRequest(t, router, http.MethodPost, "/carts", "", nil)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d", rr.Code)
+	}
+	var cart domain.Cart
+	if err := json.NewDecoder(rr.Body).Decode(&cart); err != nil {
+		t.Fatal(err)
+	}
+
+	rr = cartRequest(t, router, http.MethodPost, "/carts/"+cart.ID+"/items", "", CartItemRequest{ProductID: 1, Quantity: 2})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("add: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), `"total":{"amount":"39.80","currency":"BRL"}`) {
+		t.Errorf("expected a total of 39.80, got %s", rr.Body.String())
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       interface{}
+		wantStatus int
+	}{
+		{"exceeds stock", http.MethodPut, "/carts/" + cart.ID + "/items/1", CartItemRequest{Quantity: 3}, http.StatusConflict},
+		{"unknown product", http.MethodPost, "/carts/" + cart.ID + "/items", CartItemRequest{ProductID: 9, Quantity: 1}, http.StatusNotFound},
+		{"unknown cart", http.MethodGet, "/carts/missing", nil, http.StatusNotFound},
+		{"update", http.MethodPut, "/carts/" + cart.ID + "/items/1", CartItemRequest{Quantity: 1}, http.StatusOK},
+		{"remove", http.MethodDelete, "/carts/" + cart.ID + "/items/1", nil, http.StatusOK},
+		{"remove again", http.MethodDelete, "/carts/" + cart.ID + "/items/1", nil, http.StatusNotFound},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if rr := cartRequest(t, router, tc.method, tc.path, "", tc.body); rr.Code != tc.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tc.wantStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestCartHandler_UserCart(t *testing.T) {
+	router := newCartRouter(&storage.MockCartRepository{}, &storage.MockProductRepository{})
+	owner, other := userToken(t, "1"), userToken(t, "2")
+
+	rr := cartRequest(t, router, http.MethodPost, "/carts", owner, nil)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d", rr.Code)
+	}
+	var cart domain.Cart
+	if err := json.NewDecoder(rr.Body).Decode(&cart); err != nil {
+		t.Fatal(err)
+	}
+	if cart.UserID == nil || *cart.UserID != 1 {
+		t.Fatalf("expected the cart to belong to user 1, got %v", cart.UserID)
+	}
+
+	if rr := cartRequest(t, router, http.MethodPost, "/carts", owner, nil); rr.Code != http.StatusOK {
+		t.Errorf("second create: expected the existing cart with 200, got %d", rr.Code)
+	}
+	if rr := cartRequest(t, router, http.MethodGet, "/carts/"+cart.ID, owner, nil); rr.Code != http.StatusOK {
+		t.Errorf("owner: expected 200, got %d", rr.Code)
+	}
+	if rr := cartRequest(t, router, http.MethodGet, "/carts/"+cart.ID, other, nil); rr.Code != http.StatusForbidden {
+		t.Errorf("other user: expected 403, got %d", rr.Code)
+	}
+	if rr := cartRequest(t, router, http.MethodGet, "/carts/"+cart.ID, "", nil); rr.Code != http.StatusForbidden {
+		t.Errorf("anonymous: expected 403, got %d", rr.Code)
+	}
+	if rr := cartRequest(t, router, http.MethodGet, "/carts/"+cart.ID, "not-a-token", nil); rr.Code != http.StatusUnauthorized {
+		t.Errorf("invalid token: expected 401, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,265 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"e-commerce.com/internal/domain"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CategoryHandler serves the /categories routes.
+type CategoryHandler struct {
+	repo domain.CategoryRepository
+}
+
+// NewCategoryHandler creates a new instance of CategoryHandler.
+func NewCategoryHandler(repo domain.CategoryRepository) *CategoryHandler {
+	return &CategoryHandler{repo: repo}
+}
+
+// CreateCategory godoc
+// @Summary      Create a new category
+// @Description  Creates a category, optionally below an existing parent category.
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Param        category  body      domain.Category  true  "Category Payload"
+// @Success      201       {object}  domain.Category
+// @Failure      400       {object}  ErrorResponse
+// @Failure      500       {object}  ErrorResponse
+// @Router       /categories [post]
+func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var c domain.Category
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		respondWithDecodeError(w, err)
+		return
+	}
+	c.ID = 0
+	c.Children = nil
+
+	if err := c.Validate(); err != nil {
+		respondWithDomainError(w, err, "Invalid category data")
+		return
+	}
+
+	if err := h.repo.Save(r.Context(), &c); err != nil {
+		respondWithDomainError(w, err, "Failed to create category")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, c)
+}
+
+// ListCategories godoc
+// @Summary      List all categories
+// @Description  Returns every category as a flat list, or nested under their parents when tree=true.
+// @Tags         categories
+// @Produce      json
+// @Param        tree  query     bool  false  "Return the categories as a tree"
+// @Success      200   {array}   domain.Category
+// @Failure      500   {object}  ErrorResponse
+// @Router       /categories [get]
+func (h *CategoryHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.repo.FindAll(r.Context())
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve categories")
+		return
+	}
+
+	if tree, _ := strconv.ParseBool(r.URL.Query().Get("tree")); tree {
+		categories = domain.BuildCategoryTree(categories)
+	}
+	if categories == nil {
+		categories = []domain.Category{}
+	}
+
+	respondWithJSON(w, http.StatusOK, categories)
+}
+
+// GetCategory godoc
+// @Summary      Get a category by ID
+// @Description  Retrieves a category together with its subtree of child categories.
+// @Tags         categories
+// @Produce      json
+// @Param        id   path      int  true  "Category ID"
+// @Success      200  {object}  domain.Category
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /categories/{id} [get]
+func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	category, err := h.repo.FindByID(r.Context(), id)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve category")
+		return
+	}
+
+	all, err := h.repo.FindAll(r.Context())
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve category")
+		return
+	}
+	for _, root := range domain.BuildCategoryTree(all) {
+		if found, ok := findInTree(root, id); ok {
+			category = found
+			break
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, category)
+}
+
+// UpdateCategory godoc
+// @Summary      Update an existing category
+// @Description  Renames a category or moves it below another parent. Moving a category below one of its own descendants is rejected.
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int              true  "Category ID"
+// @Param        category  body      domain.Category  true  "Category Payload"
+// @Success      200       {object}  domain.Category
+// @Failure      400       {object}  ErrorResponse
+// @Failure      404       {object}  ErrorResponse
+// @Failure      500       {object}  ErrorResponse
+// @Router       /categories/{id} [put]
+func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	var c domain.Category
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		respondWithDecodeError(w, err)
+		return
+	}
+	c.ID = id
+	c.Children = nil
+
+	if err := c.Validate(); err != nil {
+		respondWithDomainError(w, err, "Invalid category data")
+		return
+	}
+
+	if err := h.repo.Update(r.Context(), &c); err != nil {
+		respondWithDomainError(w, err, "Failed to update category")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, c)
+}
+
+// DeleteCategory godoc
+// @Summary      Delete a category
+// @Description  Deletes a category. Categories that still have subcategories cannot be deleted.
+// @Tags         categories
+// @Produce      json
+// @Param        id   path      int  true  "Category ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /categories/{id} [delete]
+func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	if err := h.repo.Delete(r.Context(), id); err != nil {
+		respondWithDomainError(w, err, "Failed to delete category")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Category deleted successfully"})
+}
+
+// AssignProduct godoc
+// @Summary      Assign a product to a category
+// @Description  Adds the product to the category. Assigning an already assigned product is a no-op.
+// @Tags         categories
+// @Produce      json
+// @Param        id         path      int  true  "Category ID"
+// @Param        productID  path      int  true  "Product ID"
+// @Success      200        {object}  map[string]string
+// @Failure      400        {object}  ErrorResponse
+// @Failure      404        {object}  ErrorResponse
+// @Failure      500        {object}  ErrorResponse
+// @Router       /categories/{id}/products/{productID} [put]
+func (h *CategoryHandler) AssignProduct(w http.ResponseWriter, r *http.Request) {
+	categoryID, productID, ok := categoryProductParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.AssignProduct(r.Context(), categoryID, productID); err != nil {
+		respondWithDomainError(w, err, "Failed to assign product")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Product assigned successfully"})
+}
+
+// UnassignProduct godoc
+// @Summary      Remove a product from a category
+// @Description  Removes the product from the category without deleting either of them.
+// @Tags         categories
+// @Produce      json
+// @Param        id         path      int  true  "Category ID"
+// @Param        productID  path      int  true  "Product ID"
+// @Success      200        {object}  map[string]string
+// @Failure      400        {object}  ErrorResponse
+// @Failure      404        {object}  ErrorResponse
+// @Failure      500        {object}  ErrorResponse
+// @Router       /categories/{id}/products/{productID} [delete]
+func (h *CategoryHandler) UnassignProduct(w http.ResponseWriter, r *http.Request) {
+	categoryID, productID, ok := categoryProductParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.UnassignProduct(r.Context(), categoryID, productID); err != nil {
+		respondWithDomainError(w, err, "Failed to unassign product")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Product unassigned successfully"})
+}
+
+func categoryProductParams(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	categoryID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid category ID")
+		return 0, 0, false
+	}
+	productID, err := strconv.Atoi(chi.URLParam(r, "productID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return 0, 0, false
+	}
+	return categoryID, productID, true
+}
+
+func findInTree(node domain.Category, id int) (domain.Category, bool) {
+	if node.ID == id {
+		return node, true
+	}
+	for _, child := range node.Children {
+		if found, ok := findInTree(child, id); ok {
+			return found, true
+		}
+	}
+	return domain.Category{}, false
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"e-commerce.com/internal/domain"
+)
+
+// productETag is the strong entity tag of a product at the given version.
+func productETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersions parses the If-Match header into the product versions it
+// accepts. any is true when the header is missing or "*". Weak tags are
+// skipped, since If-Match uses the strong comparison, and so are versions
+// below 1: no product has one, and the repositories read 0 as "any version".
+func ifMatchVersions(r *http.Request) (versions []int, any bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "W/") || len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		if version, err := strconv.Atoi(tag[1 : len(tag)-1]); err == nil && version > 0 {
+			versions = append(versions, version)
+		}
+	}
+	return versions, false
+}
+
+// matchesVersion reports whether If-Match allows a write to a product at version.
+func matchesVersion(r *http.Request, version int) bool {
+	versions, any := ifMatchVersions(r)
+	if any {
+		return true
+	}
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified reports whether If-None-Match lists etag, using the weak
+// comparison as RFC 9110 requires for this header.
+func notModified(r *http.Request, etag string) bool {
+	header := strings.TrimSpace(r.Header.Get("If-None-Match"))
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// expectedVersion resolves If-Match into the version a write to product id
+// must find, or 0 for an unconditional write. Lists of several tags are
+// resolved against the current version.
+func (h *ProductHandler) expectedVersion(r *http.Request, id int) (int, error) {
+	versions, any := ifMatchVersions(r)
+	switch {
+	case any:
+		return 0, nil
+	case len(versions) == 1:
+		return versions[0], nil
+	case len(versions) == 0:
+		return 0, errVersionMismatch()
+	}
+	current, err := h.repo.FindByID(r.Context(), id)
+	if err != nil {
+		return 0, err
+	}
+	if !matchesVersion(r, current.Version) {
+		return 0, errVersionMismatch()
+	}
+	return current.Version, nil
+}
+
+func errVersionMismatch() error {
+	return domain.NewPreconditionFailedError("If-Match does not match the current version of the product")
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"log"
+	"net/http"
+
+	"e-commerce.com/internal/catalog"
+	"e-commerce.com/internal/domain"
+)
+
+// exportContentTypes are the media types of the export formats.
+var exportContentTypes = map[string]string{
+	catalog.FormatCSV:    "text/csv; charset=utf-8",
+	catalog.FormatNDJSON: "application/x-ndjson",
+}
+
+// ExportProducts godoc
+// @Summary      Export the catalog
+// @Description  Streams every product matching the filters of GET /products as a CSV file (the columns accepted by POST /products/import; products without a SKU cannot be imported again) or as NDJSON with one product per line, as a download. Rows are read from the database as they are written, so exports of any size use constant memory.
+// @Tags         products
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Param        format               query     string  false  "File format" Enums(csv, ndjson) default(csv)
+// @Param        category             query     int     false  "Only products assigned to this category"
+// @Param        include_descendants  query     bool    false  "Also match products in subcategories of category"
+// @Param        name                 query     string  false  "Only products whose name contains this text (case-insensitive)"
+// @Param        min_price            query     string  false  "Minimum price, inclusive"
+// @Param        max_price            query     string  false  "Maximum price, inclusive"
+// @Param        in_stock             query     bool    false  "Only products with amount greater than zero"
+// @Param        sort                 query     string  false  "Sort field" Enums(id, name, price, amount) default(id)
+// @Param        order                query     string  false  "Sort direction" Enums(asc, desc) default(asc)
+// @Success      200                  {string}  string  "Product file"
+// @Failure      400                  {object}  ErrorResponse
+// @Failure      404                  {object}  ErrorResponse
+// @Failure      500                  {object}  ErrorResponse
+// @Router       /products/export [get]
+func (h *ProductHandler) ExportProducts(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = catalog.FormatCSV
+	}
+	exporter, err := catalog.NewExporter(w, format)
+	if err != nil {
+		respondWithDomainError(w, err, "Invalid format")
+		return
+	}
+	filter, err := h.productFilter(r)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to export products")
+		return
+	}
+
+	// Headers are only committed with the first product, so a query that
+	// fails straight away still gets a proper error response.
+	started := false
+	start := func() {
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", exportContentTypes[format])
+			w.Header().Set("Content-Disposition", `attachment; filename="products.`+format+`"`)
+		}
+	}
+	err = h.repo.Stream(r.Context(), filter, func(p domain.Product) error {
+		start()
+		return exporter.Write(p)
+	})
+	if err != nil && !started {
+		respondWithDomainError(w, err, "Failed to export products")
+		return
+	}
+	start()
+	if err == nil {
+		err = exporter.Flush()
+	}
+	if err != nil {
+		// Too late for an error status: abort the response so the client
+		// sees an incomplete download instead of a silently truncated file.
+		log.Printf("Error exporting products: %v", err)
+		panic(http.ErrAbortHandler)
+	}
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"e-commerce.com/internal/domain"
+	"e-commerce.com/internal/storage"
+)
+
+func TestExportProductsHandler(t *testing.T) {
+	products := &storage.MockProductRepository{Products: []domain.Product{
+		{ID: 1, Name: "Mouse", Price: domain.NewMoney(1990, "USD"), Amount: 9, SKU: "MOUSE-1", Slug: "mouse"},
+		{ID: 2, Name: "Cable", Price: domain.NewMoney(500, "USD"), Amount: 0, Slug: "cable"},
+	}}
+	h := NewProductHandler(products, &storage.MockCategoryRepository{}, &storage.MockVariantRepository{})
+	export := func(target string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ExportProducts(rr, httptest.NewRequest("GET", target, nil))
+		return rr
+	}
+
+	rr := export("/products/export?in_stock=true")
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Fatalf("expected a CSV file, got %d %v", rr.Code, rr.Header())
+	}
+	if got := rr.Header().Get("Content-Disposition"); got != `attachment; filename="products.csv"` {
+		t.Errorf("unexpected Content-Disposition %q", got)
+	}
+	if want := "sku,name,description,price,currency,amount,slug\nMOUSE-1,Mouse,,19.90,USD,9,mouse\n"; rr.Body.String() != want {
+		t.Errorf("expected only the product in stock:\ngot  %q\nwant %q", rr.Body.String(), want)
+	}
+
+	rr = export("/products/export?format=ndjson&sort=name")
+	lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+	if rr.Header().Get("Content-Type") != "application/x-ndjson" || len(lines) != 2 || !strings.Contains(lines[0], `"name":"Cable"`) {
+		t.Errorf("expected two NDJSON lines sorted by name, got %q", rr.Body.String())
+	}
+
+	if rr := export("/products/export?format=xlsx"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown format, got %d", rr.Code)
+	}
+
+	products.Error = domain.NewUnavailableError("database unavailable", errors.New("connection refused"))
+	if rr := export("/products/export"); rr.Code != http.StatusServiceUnavailable || rr.Header().Get("Content-Disposition") != "" {
+		t.Errorf("expected a plain 503 when the export cannot start, got %d %v", rr.Code, rr.Header())
+	}
+}
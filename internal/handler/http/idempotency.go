@@ -0,0 +1,173 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"e-commerce.com/internal/auth"
+	"e-commerce.com/internal/domain"
+)
+
+// IdempotencyKeyHeader is the request header naming an idempotency key.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+const maxIdempotencyKeyLength = 255
+
+// maxIdempotentBody bounds the request bodies buffered to fingerprint them,
+// matching the largest body a JSON endpoint accepts (see maxBulkBody).
+const maxIdempotentBody = maxBulkBody
+
+// replayedHeaders are the response headers stored and replayed with a response.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// Idempotency makes POST requests carrying an Idempotency-Key header safe to
+// retry: the first response for a key is stored for ttl and replayed for
+// repeated requests with the same key and payload. Reusing a key with a
+// different payload is rejected with 422. Keys are scoped to the caller, so
+// the middleware must run after authentication, and anonymous requests with
+// a key are rejected: they would share a scope and see each other's
+// responses. Request bodies are buffered, so streaming uploads such as
+// catalog imports must not use the middleware.
+//
+// Responses with a 5xx status are not stored, and neither are handler
+// panics, so failed requests can be retried with the same key.
+func Idempotency(store domain.IdempotencyRepository, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				respondWithError(w, http.StatusBadRequest, "Invalid Idempotency-Key: must be at most 255 characters")
+				return
+			}
+
+			if _, ok := auth.ClaimsFromContext(r.Context()); !ok {
+				respondWithError(w, http.StatusBadRequest, "Invalid Idempotency-Key: requires an authenticated request")
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBody))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				respondWithError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+				return
+			}
+			if err != nil {
+				respondWithDecodeError(w, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			record := domain.IdempotencyRecord{
+				Scope:       auth.ActorFromContext(r.Context()),
+				Key:         key,
+				Fingerprint: requestFingerprint(r, body),
+				ExpiresAt:   time.Now().Add(ttl),
+			}
+			existing, err := store.Begin(r.Context(), record)
+			if err != nil {
+				respondWithDomainError(w, err, "Failed to check idempotency key")
+				return
+			}
+			if existing != nil {
+				replayIdempotent(w, record, existing)
+				return
+			}
+
+			// Finish even if the client went away; the key must not stay claimed.
+			ctx := context.WithoutCancel(r.Context())
+			defer func() {
+				if p := recover(); p != nil {
+					if err := store.Release(ctx, record.Scope, record.Key); err != nil {
+						log.Printf("Failed to release idempotency key: %v", err)
+					}
+					panic(p)
+				}
+			}()
+
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			if recorder.status >= http.StatusInternalServerError {
+				err = store.Release(ctx, record.Scope, record.Key)
+			} else {
+				err = store.Complete(ctx, record.Scope, record.Key, recorder.response())
+			}
+			if err != nil {
+				log.Printf("Failed to record idempotency key: %v", err)
+			}
+		})
+	}
+}
+
+// replayIdempotent answers a request whose key is already taken.
+func replayIdempotent(w http.ResponseWriter, record domain.IdempotencyRecord, existing *domain.IdempotencyRecord) {
+	switch {
+	case existing.Fingerprint != record.Fingerprint:
+		respondWithJSON(w, http.StatusUnprocessableEntity, ErrorResponse{
+			Error: "Idempotency-Key was already used for a different request",
+			Code:  "idempotency_key_reused",
+		})
+	case existing.Response == nil:
+		w.Header().Set("Retry-After", "1")
+		respondWithDomainError(w, domain.NewConflictError("a request with this Idempotency-Key is still being processed", nil),
+			"Request in progress")
+	default:
+		for name, value := range existing.Response.Header {
+			w.Header().Set(name, value)
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(existing.Response.Status)
+		_, _ = w.Write(existing.Response.Body)
+	}
+}
+
+// requestFingerprint identifies the request a key was first used for.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	_, _ = io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	_, _ = h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder passes a response through while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+func (rec *responseRecorder) response() domain.IdempotentResponse {
+	header := make(map[string]string)
+	for _, name := range replayedHeaders {
+		if value := rec.Header().Get(name); value != "" {
+			header[name] = value
+		}
+	}
+	return domain.IdempotentResponse{Status: rec.status, Header: header, Body: rec.body.Bytes()}
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"e-commerce.com/internal/auth"
+	"e-commerce.com/internal/storage"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestIdempotency(t *testing.T) {
+	now := time.Now()
+	store := &storage.MockIdempotencyRepository{Now: func() time.Time { return now }}
+	calls := 0
+	status := http.StatusCreated
+	handler := Idempotency(store, time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if status < 0 {
+			panic("handler failed")
+		}
+		w.Header().Set("Location", "/products/"+strconv.Itoa(calls))
+		respondWithJSON(w, status, map[string]int{"id": calls})
+	}))
+
+	post := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(body))
+		req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "7"}}))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	first := post("k1", `{"name":"Keyboard"}`)
+	if first.Code != http.StatusCreated || calls != 1 {
+		t.Fatalf("expected 201 from the handler, got %d after %d calls", first.Code, calls)
+	}
+
+	replay := post("k1", `{"name":"Keyboard"}`)
+	if calls != 1 {
+		t.Fatalf("expected the replay not to reach the handler, got %d calls", calls)
+	}
+	if replay.Code != http.StatusCreated || replay.Body.String() != first.Body.String() {
+		t.Errorf("expected the stored response to be replayed, got %d %q", replay.Code, replay.Body.String())
+	}
+	if replay.Header().Get("Location") != "/products/1" || replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("expected replayed headers, got %v", replay.Header())
+	}
+
+	if rr := post("k1", `{"name":"Mouse"}`); rr.Code != http.StatusUnprocessableEntity || calls != 1 {
+		t.Errorf("expected 422 for a reused key, got %d after %d calls", rr.Code, calls)
+	}
+	if rr := post("", `{"name":"Keyboard"}`); rr.Code != http.StatusCreated || calls != 2 {
+		t.Errorf("expected requests without a key to pass through, got %d after %d calls", rr.Code, calls)
+	}
+
+	// Server errors release the key so the request can be retried.
+	status = http.StatusInternalServerError
+	post("k2", `{}`)
+	status = http.StatusCreated
+	if rr := post("k2", `{}`); rr.Code != http.StatusCreated || calls != 4 {
+		t.Errorf("expected a retry after a 500 to reach the handler, got %d after %d calls", rr.Code, calls)
+	}
+
+	// A panicking handler releases the key too.
+	func() {
+		defer func() { _ = recover() }()
+		status = -1
+		post("k3", `{}`)
+	}()
+	status = http.StatusCreated
+	if rr := post("k3", `{}`); rr.Code != http.StatusCreated || calls != 6 {
+		t.Errorf("expected a retry after a panic to reach the handler, got %d after %d calls", rr.Code, calls)
+	}
+
+	if rr := post("k4", strings.Repeat(" ", maxIdempotentBody+1)); rr.Code != http.StatusRequestEntityTooLarge || calls != 6 {
+		t.Errorf("expected 413 for an oversized body, got %d after %d calls", rr.Code, calls)
+	}
+
+	anonymous := httptest.NewRequest(http.MethodPost, "/carts", strings.NewReader(`{}`))
+	anonymous.Header.Set(IdempotencyKeyHeader, "k1")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, anonymous)
+	if rr.Code != http.StatusBadRequest || calls != 6 {
+		t.Errorf("expected 400 for an anonymous key, got %d after %d calls", rr.Code, calls)
+	}
+
+	// Expired keys can be used again.
+	now = now.Add(2 * time.Hour)
+	if rr := post("k1", `{"name":"Mouse"}`); rr.Code != http.StatusCreated || calls != 7 {
+		t.Errorf("expected an expired key to be reusable, got %d after %d calls", rr.Code, calls)
+	}
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+
+	"e-commerce.com/internal/catalog"
+	"e-commerce.com/internal/domain"
+)
+
+// maxImportBody bounds the size of an uploaded catalog file.
+const maxImportBody = 256 << 20
+
+// importFormats maps the media types accepted by ImportProducts to file formats.
+var importFormats = map[string]string{
+	"text/csv":             catalog.FormatCSV,
+	"application/x-ndjson": catalog.FormatNDJSON,
+	"application/jsonl":    catalog.FormatNDJSON,
+}
+
+// ImportProducts godoc
+// @Summary      Import products from a CSV or NDJSON file
+// @Description  Streams a catalog file and upserts every row by SKU with the same validation as creating a product. CSV files need a header naming the columns sku, name, description, price, currency and amount, and may add slug, since a row replaces the whole product; NDJSON files hold one product object per line with the same fields, the currency given in the price object. Invalid rows are skipped. The response is a CSV report (line, sku, error) of the rejected rows, and the X-Import-Rows, X-Import-Created, X-Import-Updated and X-Import-Failed headers summarize the run.
+// @Tags         products
+// @Accept       text/csv
+// @Accept       application/x-ndjson
+// @Produce      text/csv
+// @Param        format  query     string  false  "File format; defaults to the one named by Content-Type" Enums(csv, ndjson)
+// @Success      200     {string}  string  "CSV report of rejected rows"
+// @Failure      400     {object}  ErrorResponse
+// @Failure      413     {object}  ErrorResponse
+// @Failure      500     {object}  ErrorResponse
+// @Router       /products/import [post]
+func (h *ProductHandler) ImportProducts(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		format = importFormats[mediaType]
+	}
+	if format == "" {
+		respondWithError(w, http.StatusBadRequest, "Invalid format: pass format=csv or format=ndjson, or send text/csv or application/x-ndjson")
+		return
+	}
+	if err := catalog.ValidateFormat(format); err != nil {
+		respondWithDomainError(w, err, "Invalid format")
+		return
+	}
+
+	// The report is spooled to disk so the summary headers can precede it
+	// without holding every rejected row in memory.
+	spool, err := os.CreateTemp("", "import-report-*.csv")
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to import products")
+		return
+	}
+	defer func() {
+		_ = spool.Close()
+		if err := os.Remove(spool.Name()); err != nil {
+			log.Printf("Error removing import report: %v", err)
+		}
+	}()
+
+	report, err := catalog.NewReportWriter(spool)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to import products")
+		return
+	}
+	summary, err := catalog.Import(r.Context(), h.repo, http.MaxBytesReader(w, r.Body, maxImportBody), format, report.Write)
+	if err == nil {
+		err = report.Flush()
+	}
+	var domainErr *domain.Error
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &domainErr):
+		respondWithDomainError(w, err, "Failed to import products")
+		return
+	case errors.As(err, &tooLarge):
+		respondWithError(w, http.StatusRequestEntityTooLarge, "The uploaded file is too large")
+		return
+	case err != nil:
+		log.Printf("Error reading import: %v", err)
+		respondWithError(w, http.StatusBadRequest, "Failed to read the uploaded file")
+		return
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		respondWithDomainError(w, err, "Failed to import products")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="import-report.csv"`)
+	w.Header().Set("X-Import-Rows", strconv.Itoa(summary.Rows))
+	w.Header().Set("X-Import-Created", strconv.Itoa(summary.Created))
+	w.Header().Set("X-Import-Updated", strconv.Itoa(summary.Updated))
+	w.Header().Set("X-Import-Failed", strconv.Itoa(summary.Failed))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, spool); err != nil {
+		log.Printf("Error writing import report: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"e-commerce.com/internal/storage"
+)
+
+func TestImportProductsHandler(t *testing.T) {
+	products := &storage.MockProductRepository{}
+	h := NewProductHandler(products, &storage.MockCategoryRepository{}, &storage.MockVariantRepository{})
+
+	importFile := func(target, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", target, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+		h.ImportProducts(rr, req)
+		return rr
+	}
+
+	rr := importFile("/products/import", "text/csv; charset=utf-8", "sku,name,description,price,currency,amount\nKB-1,Keyboard,,49.90,BRL,5\nKB-2,,,1.00,BRL,1\n")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body)
+	}
+	if rr.Header().Get("X-Import-Created") != "1" || rr.Header().Get("X-Import-Failed") != "1" {
+		t.Errorf("expected one created and one failed row, got %v", rr.Header())
+	}
+	if !strings.HasPrefix(rr.Header().Get("Content-Disposition"), "attachment") {
+		t.Errorf("expected the report to be an attachment, got %q", rr.Header().Get("Content-Disposition"))
+	}
+	lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+	if len(lines) != 2 || lines[0] != "line,sku,error" || !strings.HasPrefix(lines[1], "3,KB-2,") {
+		t.Errorf("expected a report with the rejected third line, got %q", rr.Body.String())
+	}
+
+	rr = importFile("/products/import?format=ndjson", "application/octet-stream", `{"sku": "KB-1", "name": "Keyboard", "description": "", "price": {"amount": "39.90", "currency": "BRL"}, "amount": 7}`)
+	if rr.Code != http.StatusOK || rr.Header().Get("X-Import-Updated") != "1" || len(products.Products) != 1 {
+		t.Errorf("expected the keyboard to be updated by SKU, got %d %v", rr.Code, rr.Header())
+	}
+
+	if rr := importFile("/products/import", "application/json", "[]"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a recognised format, got %d", rr.Code)
+	}
+	if rr := importFile("/products/import", "text/csv", "sku,name\n"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a header without required columns, got %d", rr.Code)
+	}
+}
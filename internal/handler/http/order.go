@@ -0,0 +1,283 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"e-commerce.com/internal/auth"
+	"e-commerce.com/internal/domain"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// OrderHandler serves the /orders routes. Every route requires an
+// authenticated user.
+type OrderHandler struct {
+	orders   domain.OrderRepository
+	carts    domain.CartRepository
+	payments domain.PaymentRepository
+	provider domain.PaymentProvider
+}
+
+// NewOrderHandler creates a new instance of OrderHandler. The payments and
+// provider are used to void the open authorizations of cancelled orders.
+func NewOrderHandler(orders domain.OrderRepository, carts domain.CartRepository, payments domain.PaymentRepository, provider domain.PaymentProvider) *OrderHandler {
+	return &OrderHandler{orders: orders, carts: carts, payments: payments, provider: provider}
+}
+
+// PlaceOrderRequest is the body of POST /orders. Exactly one of CartID and
+// Items must be given.
+type PlaceOrderRequest struct {
+	CartID string             `json:"cart_id,omitempty"`
+	Items  []domain.OrderLine `json:"items,omitempty"`
+}
+
+// PlaceOrder godoc
+// @Summary      Place an order
+// @Description  Checks out a cart or an explicit list of items. Stock for every item is reserved in the same transaction that creates the order, at the products' current prices; if any product lacks stock nothing is reserved. Items with a variant_id are taken from that variant's stock at its effective price. A checked out cart is deleted.
+// @Tags         orders
+// @Accept       json
+// @Produce      json
+// @Param        order  body      PlaceOrderRequest  true  "Checkout Payload"
+// @Success      201    {object}  domain.Order
+// @Failure      400    {object}  ErrorResponse
+// @Failure      401    {object}  ErrorResponse
+// @Failure      403    {object}  ErrorResponse
+// @Failure      404    {object}  ErrorResponse
+// @Failure      409    {object}  ErrorResponse
+// @Failure      500    {object}  ErrorResponse
+// @Router       /orders [post]
+func (h *OrderHandler) PlaceOrder(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	var req PlaceOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithDecodeError(w, err)
+		return
+	}
+	if (req.CartID == "") == (len(req.Items) == 0) {
+		respondWithError(w, http.StatusBadRequest, "Provide either cart_id or items")
+		return
+	}
+
+	lines := req.Items
+	if req.CartID != "" {
+		cart, err := h.carts.FindByID(r.Context(), req.CartID)
+		if err != nil {
+			respondWithDomainError(w, err, "Failed to retrieve cart")
+			return
+		}
+		if cart.UserID != nil && !ownsUserResource(r, *cart.UserID) {
+			respondWithDomainError(w, domain.NewForbiddenError("cart belongs to another user"), "Forbidden")
+			return
+		}
+		for _, item := range cart.Items {
+			lines = append(lines, domain.OrderLine{ProductID: item.ProductID, Quantity: item.Quantity})
+		}
+	}
+
+	order, err := domain.NewOrder(&userID, lines)
+	if err != nil {
+		respondWithDomainError(w, err, "Invalid order data")
+		return
+	}
+	if err := h.orders.Place(r.Context(), &order); err != nil {
+		respondWithDomainError(w, err, "Failed to place order")
+		return
+	}
+
+	if req.CartID != "" {
+		// The order already exists; a leftover cart is not worth failing over.
+		if err := h.carts.Delete(r.Context(), req.CartID); err != nil {
+			log.Printf("Error deleting cart %s after checkout: %v", req.CartID, err)
+		}
+	}
+
+	respondWithJSON(w, http.StatusCreated, order)
+}
+
+// ListOrders godoc
+// @Summary      List my orders
+// @Description  Returns the authenticated user's orders, newest first.
+// @Tags         orders
+// @Produce      json
+// @Success      200  {array}   domain.Order
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /orders [get]
+func (h *OrderHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	orders, err := h.orders.FindByUser(r.Context(), userID)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve orders")
+		return
+	}
+	if orders == nil {
+		orders = []domain.Order{}
+	}
+
+	respondWithJSON(w, http.StatusOK, orders)
+}
+
+// GetOrder godoc
+// @Summary      Get an order by ID
+// @Description  Returns one of the authenticated user's orders. Admins can read any order.
+// @Tags         orders
+// @Produce      json
+// @Param        id   path      int  true  "Order ID"
+// @Success      200  {object}  domain.Order
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /orders/{id} [get]
+func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	order, ok := h.loadOrder(w, r)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, order)
+}
+
+// TransitionRequest is the body of POST /orders/{id}/transitions.
+type TransitionRequest struct {
+	Status string `json:"status"`
+	Note   string `json:"note,omitempty"`
+}
+
+// TransitionOrder godoc
+// @Summary      Change the status of an order
+// @Description  Moves an order along its lifecycle: pending → paid → fulfilled → shipped → delivered, with pending orders able to be cancelled and paid ones refunded. Cancelling voids the order's authorized payments and, like refunding before shipment, returns the items to stock. Orders only become paid when their payment is captured, and refunded when it is refunded through POST /payments/{id}/refund. Customers may only cancel their own orders; admins may make any other legal transition.
+// @Tags         orders
+// @Accept       json
+// @Produce      json
+// @Param        id          path      int                true  "Order ID"
+// @Param        transition  body      TransitionRequest  true  "Transition Payload"
+// @Success      200         {object}  domain.Order
+// @Failure      400         {object}  ErrorResponse
+// @Failure      401         {object}  ErrorResponse
+// @Failure      402         {object}  ErrorResponse
+// @Failure      403         {object}  ErrorResponse
+// @Failure      404         {object}  ErrorResponse
+// @Failure      409         {object}  ErrorResponse
+// @Failure      500         {object}  ErrorResponse
+// @Failure      503         {object}  ErrorResponse
+// @Router       /orders/{id}/transitions [post]
+func (h *OrderHandler) TransitionOrder(w http.ResponseWriter, r *http.Request) {
+	order, ok := h.loadOrder(w, r)
+	if !ok {
+		return
+	}
+	var req TransitionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithDecodeError(w, err)
+		return
+	}
+	to, err := domain.ParseOrderStatus(req.Status)
+	if err != nil {
+		respondWithDomainError(w, err, "Invalid order status")
+		return
+	}
+
+	switch to {
+	case domain.OrderStatusPaid:
+		respondWithDomainError(w, domain.NewForbiddenError("orders become paid when their payment is captured"), "Forbidden")
+		return
+	case domain.OrderStatusRefunded:
+		// Refunding here would leave the money captured at the provider.
+		respondWithDomainError(w, domain.NewForbiddenError("orders are refunded by refunding their payment"), "Forbidden")
+		return
+	}
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	if to != domain.OrderStatusCancelled && !claims.HasAnyRole(auth.RoleAdmin) {
+		respondWithDomainError(w, domain.NewForbiddenError("only admins can move orders to "+string(to)), "Forbidden")
+		return
+	}
+
+	if to == domain.OrderStatusCancelled && order.Status.CanTransitionTo(to) {
+		// Void first: if that fails the order stays pending with its
+		// payment intact, rather than cancelled with the money on hold.
+		if err := voidAuthorized(r.Context(), h.payments, h.provider, order.ID); err != nil {
+			respondWithDomainError(w, err, "Failed to void payment")
+			return
+		}
+	}
+	order, err = h.orders.Transition(r.Context(), order.ID, to, auth.ActorFromContext(r.Context()), req.Note)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to update order")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, order)
+}
+
+// OrderHistory godoc
+// @Summary      Get the status history of an order
+// @Description  Lists every status change of the order, oldest first, with who made it and when.
+// @Tags         orders
+// @Produce      json
+// @Param        id   path      int  true  "Order ID"
+// @Success      200  {array}   domain.OrderTransition
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /orders/{id}/history [get]
+func (h *OrderHandler) OrderHistory(w http.ResponseWriter, r *http.Request) {
+	order, ok := h.loadOrder(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := h.orders.History(r.Context(), order.ID)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve order history")
+		return
+	}
+	if history == nil {
+		history = []domain.OrderTransition{}
+	}
+
+	respondWithJSON(w, http.StatusOK, history)
+}
+
+func (h *OrderHandler) loadOrder(w http.ResponseWriter, r *http.Request) (domain.Order, bool) {
+	return loadVisibleOrder(w, r, h.orders)
+}
+
+// loadVisibleOrder fetches the order named in the URL if the caller may see
+// it. Other users' orders are reported as not found.
+func loadVisibleOrder(w http.ResponseWriter, r *http.Request, orders domain.OrderRepository) (domain.Order, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid order ID")
+		return domain.Order{}, false
+	}
+
+	order, err := orders.FindByID(r.Context(), id)
+	if err == nil && !canSeeOrder(r, order) {
+		err = domain.NewNotFoundError("order not found")
+	}
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve order")
+		return domain.Order{}, false
+	}
+	return order, true
+}
+
+func canSeeOrder(r *http.Request, order domain.Order) bool {
+	if order.UserID != nil {
+		return ownsUserResource(r, *order.UserID)
+	}
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	return ok && claims.HasAnyRole(auth.RoleAdmin)
+}
@@ -0,0 +1,221 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"e-commerce.com/internal/domain"
+	"e-commerce.com/internal/payment"
+	"e-commerce.com/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func newOrderRouter(orders *storage.MockOrderRepository, carts *storage.MockCartRepository) http.Handler {
+	h := NewOrderHandler(orders, carts, &storage.MockPaymentRepository{}, payment.NewFakeProvider())
+	r := chi.NewRouter()
+	r.Use(OptionalAuthenticate(newTestVerifier()))
+	r.Post("/orders", h.PlaceOrder)
+	r.Get("/orders", h.ListOrders)
+	r.Get("/orders/{id}", h.GetOrder)
+	return r
+}
+
+func TestOrderHandler_PlaceOrder(t *testing.T) {
+	products := &storage.MockProductRepository{Products: []domain.Product{
+		{ID: 1, Name: "Mug", Price: domain.NewMoney(1990, "BRL"), Amount: 2},
+		{ID: 2, Name: "Pen", Price: domain.NewMoney(250, "BRL"), Amount: 10},
+	}}
+	orders := &storage.MockOrderRepository{Products: products}
+	router := newOrderRouter(orders, &storage.MockCartRepository{})
+	token := userToken(t, "1")
+
+	tests := []struct {
+		name       string
+		token      string
+		body       PlaceOrderRequest
+		wantStatus int
+	}{
+		{"anonymous", "", PlaceOrderRequest{Items: []domain.OrderLine{{ProductID: 1, Quantity: 1}}}, http.StatusUnauthorized},
+		{"no items", token, PlaceOrderRequest{}, http.StatusBadRequest},
+		{"unknown product", token, PlaceOrderRequest{Items: []domain.OrderLine{{ProductID: 9, Quantity: 1}}}, http.StatusNotFound},
+		{"merged lines exceed stock", token, PlaceOrderRequest{Items: []domain.OrderLine{{ProductID: 1, Quantity: 2}, {ProductID: 2, Quantity: 1}, {ProductID: 1, Quantity: 1}}}, http.StatusConflict},
+		{"success", token, PlaceOrderRequest{Items: []domain.OrderLine{{ProductID: 2, Quantity: 4}, {ProductID: 1, Quantity: 2}}}, http.StatusCreated},
+		{"last unit already sold", token, PlaceOrderRequest{Items: []domain.OrderLine{{ProductID: 1, Quantity: 1}}}, http.StatusConflict},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if rr := cartRequest(t, router, http.MethodPost, "/orders", tc.token, tc.body); rr.Code != tc.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tc.wantStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	if got := products.Products[0].Amount; got != 0 {
+		t.Errorf("expected the mug to be sold out, %d left", got)
+	}
+	if got := products.Products[1].Amount; got != 6 {
+		t.Errorf("expected 6 pens left, got %d", got)
+	}
+	if len(orders.Orders) != 1 {
+		t.Fatalf("expected exactly one order, got %d", len(orders.Orders))
+	}
+	if got := orders.Orders[0].Total; got != domain.NewMoney(2*1990+4*250, "BRL") {
+		t.Errorf("expected a total of 49.80, got %s", got)
+	}
+
+	if rr := cartRequest(t, router, http.MethodGet, "/orders/1", token, nil); rr.Code != http.StatusOK {
+		t.Errorf("owner: expected 200, got %d", rr.Code)
+	}
+	if rr := cartRequest(t, router, http.MethodGet, "/orders/1", userToken(t, "2"), nil); rr.Code != http.StatusNotFound {
+		t.Errorf("other user: expected 404, got %d", rr.Code)
+	}
+}
+
+func TestOrderHandler_PlaceOrderVariant(t *testing.T) {
+	products := &storage.MockProductRepository{Products: []domain.Product{
+		{ID: 1, Name: "Shirt", Price: domain.NewMoney(4990, "BRL"), Amount: 7},
+	}}
+	large := domain.NewMoney(5490, "BRL")
+	variants := &storage.MockVariantRepository{Products: products, Variants: []domain.Variant{
+		{ID: 1, ProductID: 1, SKU: "SHIRT-M", Amount: 3},
+		{ID: 2, ProductID: 1, SKU: "SHIRT-L", Price: &large, Amount: 1},
+	}}
+	orders := &storage.MockOrderRepository{Products: products, Variants: variants}
+	router := newOrderRouter(orders, &storage.MockCartRepository{})
+	token := userToken(t, "1")
+
+	tests := []struct {
+		name       string
+		lines      []domain.OrderLine
+		wantStatus int
+	}{
+		{"unknown variant", []domain.OrderLine{{ProductID: 1, VariantID: 9, Quantity: 1}}, http.StatusNotFound},
+		{"variant stock exceeded", []domain.OrderLine{{ProductID: 1, VariantID: 2, Quantity: 2}}, http.StatusConflict},
+		{"success", []domain.OrderLine{{ProductID: 1, VariantID: 2, Quantity: 1}, {ProductID: 1, VariantID: 1, Quantity: 2}}, http.StatusCreated},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := cartRequest(t, router, http.MethodPost, "/orders", token, PlaceOrderRequest{Items: tc.lines})
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tc.wantStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	if got := products.Products[0].Amount; got != 7 {
+		t.Errorf("expected the product's own stock to be untouched, got %d", got)
+	}
+	if m, l := variants.Variants[0].Amount, variants.Variants[1].Amount; m != 1 || l != 0 {
+		t.Errorf("expected 1 medium and 0 large left, got %d and %d", m, l)
+	}
+	if len(orders.Orders) != 1 {
+		t.Fatalf("expected exactly one order, got %d", len(orders.Orders))
+	}
+	order := orders.Orders[0]
+	if order.Items[0].Name != "Shirt (SHIRT-M)" || order.Items[1].UnitPrice != large {
+		t.Errorf("unexpected items %+v", order.Items)
+	}
+	if got := order.Total; got != domain.NewMoney(2*4990+5490, "BRL") {
+		t.Errorf("expected a total of 154.70, got %s", got)
+	}
+}
+
+func TestOrderHandler_CheckoutCart(t *testing.T) {
+	products := &storage.MockProductRepository{Products: []domain.Product{
+		{ID: 1, Name: "Mug", Price: domain.NewMoney(1990, "BRL"), Amount: 5},
+	}}
+	carts := &storage.MockCartRepository{}
+	router := newOrderRouter(&storage.MockOrderRepository{Products: products}, carts)
+
+	cart := domain.Cart{}
+	if err := carts.Create(t.Context(), &cart); err != nil {
+		t.Fatal(err)
+	}
+	_, err := carts.Update(t.Context(), cart.ID, func(cart *domain.Cart) error {
+		return cart.AddItem(products.Products[0], 3)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := cartRequest(t, router, http.MethodPost, "/orders", userToken(t, "1"), PlaceOrderRequest{CartID: cart.ID})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var order domain.Order
+	if err := json.NewDecoder(rr.Body).Decode(&order); err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != domain.OrderStatusPending || len(order.Items) != 1 || order.Items[0].Quantity != 3 {
+		t.Errorf("unexpected order %+v", order)
+	}
+	if len(carts.Carts) != 0 {
+		t.Error("expected the cart to be deleted after checkout")
+	}
+	if got := products.Products[0].Amount; got != 2 {
+		t.Errorf("expected 2 mugs left, got %d", got)
+	}
+}
+
+func TestOrderHandler_TransitionOrder(t *testing.T) {
+	products := &storage.MockProductRepository{Products: []domain.Product{
+		{ID: 1, Name: "Mug", Price: domain.NewMoney(1990, "BRL"), Amount: 5},
+	}}
+	orders := &storage.MockOrderRepository{Products: products}
+	h := NewOrderHandler(orders, &storage.MockCartRepository{}, &storage.MockPaymentRepository{}, payment.NewFakeProvider())
+	router := chi.NewRouter()
+	router.Use(OptionalAuthenticate(newTestVerifier()))
+	router.Post("/orders", h.PlaceOrder)
+	router.Post("/orders/{id}/transitions", h.TransitionOrder)
+	router.Get("/orders/{id}/history", h.OrderHistory)
+
+	customer := userToken(t, "1")
+	admin := adminToken(t)
+	for i := 0; i < 2; i++ {
+		rr := cartRequest(t, router, http.MethodPost, "/orders", customer, PlaceOrderRequest{Items: []domain.OrderLine{{ProductID: 1, Quantity: 2}}})
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("place: expected 201, got %d", rr.Code)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		path       string
+		status     string
+		wantStatus int
+	}{
+		{"customer cannot ship", customer, "/orders/1/transitions", "shipped", http.StatusForbidden},
+		{"unknown status", admin, "/orders/1/transitions", "lost", http.StatusBadRequest},
+		{"illegal transition", admin, "/orders/1/transitions", "shipped", http.StatusConflict},
+		{"paid only through a payment", admin, "/orders/1/transitions", "paid", http.StatusForbidden},
+		{"customer cancels", customer, "/orders/2/transitions", "cancelled", http.StatusOK},
+		{"cancelled order cannot be cancelled again", customer, "/orders/2/transitions", "cancelled", http.StatusConflict},
+		{"refunded only through a payment", admin, "/orders/1/transitions", "refunded", http.StatusForbidden},
+		{"other user cannot see the order", userToken(t, "2"), "/orders/2/transitions", "cancelled", http.StatusNotFound},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := cartRequest(t, router, http.MethodPost, tc.path, tc.token, TransitionRequest{Status: tc.status})
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tc.wantStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	// Order 2 was cancelled, so its two units are back in stock.
+	if got := products.Products[0].Amount; got != 3 {
+		t.Errorf("expected 3 mugs in stock, got %d", got)
+	}
+
+	rr := cartRequest(t, router, http.MethodGet, "/orders/2/history", admin, nil)
+	var history []domain.OrderTransition
+	if err := json.NewDecoder(rr.Body).Decode(&history); err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].From != domain.OrderStatusPending || history[0].To != domain.OrderStatusCancelled || history[0].Actor != "user:1" {
+		t.Errorf("unexpected history %+v", history)
+	}
+}
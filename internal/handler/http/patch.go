@@ -0,0 +1,92 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"e-commerce.com/internal/domain"
+)
+
+// mergePatchMediaType is the media type of RFC 7396 JSON merge patches.
+const mergePatchMediaType = "application/merge-patch+json"
+
+// isMergePatch reports whether the request body is a merge patch. Plain
+// application/json is accepted too, since many clients cannot set the
+// specific media type.
+func isMergePatch(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return r.Header.Get("Content-Type") == ""
+	}
+	return mediaType == mergePatchMediaType || mediaType == "application/json"
+}
+
+// applyMergePatch applies an RFC 7396 merge patch to the JSON document doc.
+// Numbers keep their literal text, so prices are never rounded through float64.
+func applyMergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decodeJSONValue(doc)
+	if err != nil {
+		return nil, err
+	}
+	p, err := decodeJSONValue(patch)
+	if err != nil {
+		return nil, domain.NewValidationError("Invalid request payload: malformed merge patch")
+	}
+	return json.Marshal(mergePatch(target, p))
+}
+
+// keepPriceCurrency rewrites a bare amount given as the price in a product
+// patch, such as {"price": "9.90"}, as {"price": {"amount": "9.90"}}. It then
+// merges into the current price and keeps its currency, instead of replacing
+// the price with one in domain.DefaultCurrency.
+func keepPriceCurrency(patch []byte) ([]byte, error) {
+	p, err := decodeJSONValue(patch)
+	if err != nil {
+		return nil, domain.NewValidationError("Invalid request payload: malformed merge patch")
+	}
+	object, ok := p.(map[string]interface{})
+	if !ok {
+		return patch, nil
+	}
+	switch price := object["price"].(type) {
+	case string:
+		object["price"] = map[string]interface{}{"amount": price}
+	case json.Number:
+		object["price"] = map[string]interface{}{"amount": price.String()}
+	default:
+		return patch, nil
+	}
+	return json.Marshal(object)
+}
+
+// mergePatch implements the MergePatch function of RFC 7396, section 2.
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = mergePatch(targetObject[name], value)
+	}
+	return targetObject
+}
+
+func decodeJSONValue(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// Test cases from RFC 7396, appendix A.
+func TestApplyMergePatch(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		// Numbers keep their literal text.
+		{`{"price":"1.00"}`, `{"price":19.90}`, `{"price":19.90}`},
+	}
+	for _, tt := range tests {
+		got, err := applyMergePatch([]byte(tt.doc), []byte(tt.patch))
+		if err != nil {
+			t.Errorf("patch %s onto %s: %v", tt.patch, tt.doc, err)
+			continue
+		}
+		var gotValue, wantValue interface{}
+		_ = json.Unmarshal(got, &gotValue)
+		_ = json.Unmarshal([]byte(tt.want), &wantValue)
+		if !reflect.DeepEqual(gotValue, wantValue) {
+			t.Errorf("patch %s onto %s: got %s, want %s", tt.patch, tt.doc, got, tt.want)
+		}
+	}
+
+	if got, _ := applyMergePatch([]byte(`{}`), []byte(`{"price":19.90}`)); string(got) != `{"price":19.90}` {
+		t.Errorf("expected the number literal to survive, got %s", got)
+	}
+	if _, err := applyMergePatch([]byte(`{}`), []byte(`{"a":`)); err == nil {
+		t.Error("expected an error for a malformed patch")
+	}
+}
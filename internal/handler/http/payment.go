@@ -0,0 +1,348 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"e-commerce.com/internal/domain"
+	"e-commerce.com/internal/payment"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxWebhookBody bounds the size of a webhook request body.
+const maxWebhookBody = 1 << 20
+
+// PaymentHandler serves the payment routes and the provider webhook. An
+// order only becomes paid when one of its payments is captured, either
+// through POST /payments/{id}/capture or a payment.captured webhook.
+type PaymentHandler struct {
+	payments      domain.PaymentRepository
+	orders        domain.OrderRepository
+	provider      domain.PaymentProvider
+	webhookSecret []byte
+	now           func() time.Time
+}
+
+// NewPaymentHandler creates a new instance of PaymentHandler. Webhooks must
+// be signed with webhookSecret; when it is empty every webhook is rejected.
+func NewPaymentHandler(payments domain.PaymentRepository, orders domain.OrderRepository, provider domain.PaymentProvider, webhookSecret []byte) *PaymentHandler {
+	return &PaymentHandler{payments: payments, orders: orders, provider: provider, webhookSecret: webhookSecret, now: time.Now}
+}
+
+// AuthorizePaymentRequest is the body of POST /orders/{id}/payments.
+type AuthorizePaymentRequest struct {
+	// Source identifies the means of payment at the provider, e.g. a card token.
+	Source string `json:"source"`
+}
+
+// AuthorizePayment godoc
+// @Summary      Pay for an order
+// @Description  Authorizes the order total with the payment provider. The order stays pending until the payment is captured. A declined authorization is stored as a failed payment and answered with 402. An order with an open authorization cannot be authorized again (409).
+// @Tags         payments
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                      true  "Order ID"
+// @Param        payment  body      AuthorizePaymentRequest  true  "Payment Payload"
+// @Success      201      {object}  domain.Payment
+// @Failure      400      {object}  ErrorResponse
+// @Failure      401      {object}  ErrorResponse
+// @Failure      402      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      409      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Failure      503      {object}  ErrorResponse
+// @Router       /orders/{id}/payments [post]
+func (h *PaymentHandler) AuthorizePayment(w http.ResponseWriter, r *http.Request) {
+	order, ok := loadVisibleOrder(w, r, h.orders)
+	if !ok {
+		return
+	}
+	var req AuthorizePaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithDecodeError(w, err)
+		return
+	}
+	if order.Status != domain.OrderStatusPending {
+		respondWithDomainError(w, domain.NewConflictError("only pending orders can be paid", nil), "Failed to authorize payment")
+		return
+	}
+	existing, err := h.payments.FindByOrder(r.Context(), order.ID)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to authorize payment")
+		return
+	}
+	for _, p := range existing {
+		if p.Status == domain.PaymentStatusAuthorized {
+			respondWithDomainError(w, domain.NewConflictError("order already has an authorized payment", nil), "Failed to authorize payment")
+			return
+		}
+	}
+
+	result, err := h.provider.Authorize(r.Context(), order.ID, order.Total, req.Source)
+	if err != nil {
+		respondWithDomainError(w, domain.NewUnavailableError("payment provider unavailable", err), "Failed to authorize payment")
+		return
+	}
+	p := domain.Payment{
+		OrderID:     order.ID,
+		Provider:    h.provider.Name(),
+		ProviderRef: result.ProviderRef,
+		Status:      domain.PaymentStatusAuthorized,
+		Amount:      order.Total,
+	}
+	if !result.Approved {
+		p.Status = domain.PaymentStatusFailed
+		p.FailureReason = result.Reason
+	}
+	if err := h.payments.Save(r.Context(), &p); err != nil {
+		if result.Approved && errors.Is(err, domain.ErrConflict) {
+			// A concurrent request authorized the order first; release
+			// this hold rather than leave it unrecorded.
+			if _, err := h.provider.Void(r.Context(), p.ProviderRef); err != nil {
+				log.Printf("Error voiding duplicate authorization %s of order %d: %v", p.ProviderRef, order.ID, err)
+			}
+		}
+		respondWithDomainError(w, err, "Failed to authorize payment")
+		return
+	}
+	if !result.Approved {
+		respondWithDomainError(w, domain.NewPaymentDeclinedError("payment declined: "+result.Reason), "Payment declined")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, p)
+}
+
+// ListOrderPayments godoc
+// @Summary      List the payments of an order
+// @Description  Returns every payment attempt for the order, oldest first.
+// @Tags         payments
+// @Produce      json
+// @Param        id   path      int  true  "Order ID"
+// @Success      200  {array}   domain.Payment
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /orders/{id}/payments [get]
+func (h *PaymentHandler) ListOrderPayments(w http.ResponseWriter, r *http.Request) {
+	order, ok := loadVisibleOrder(w, r, h.orders)
+	if !ok {
+		return
+	}
+
+	payments, err := h.payments.FindByOrder(r.Context(), order.ID)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve payments")
+		return
+	}
+	if payments == nil {
+		payments = []domain.Payment{}
+	}
+
+	respondWithJSON(w, http.StatusOK, payments)
+}
+
+// CapturePayment godoc
+// @Summary      Capture a payment
+// @Description  Collects an authorized payment and marks its order as paid.
+// @Tags         payments
+// @Produce      json
+// @Param        id   path      int  true  "Payment ID"
+// @Success      200  {object}  domain.Payment
+// @Failure      400  {object}  ErrorResponse
+// @Failure      402  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Router       /payments/{id}/capture [post]
+func (h *PaymentHandler) CapturePayment(w http.ResponseWriter, r *http.Request) {
+	h.operate(w, r, domain.PaymentStatusCaptured, func(ctx context.Context, p domain.Payment) (domain.PaymentResult, error) {
+		return h.provider.Capture(ctx, p.ProviderRef, p.Amount)
+	})
+}
+
+// VoidPayment godoc
+// @Summary      Void a payment
+// @Description  Releases an authorized payment that was not captured. The order stays pending.
+// @Tags         payments
+// @Produce      json
+// @Param        id   path      int  true  "Payment ID"
+// @Success      200  {object}  domain.Payment
+// @Failure      400  {object}  ErrorResponse
+// @Failure      402  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Router       /payments/{id}/void [post]
+func (h *PaymentHandler) VoidPayment(w http.ResponseWriter, r *http.Request) {
+	h.operate(w, r, domain.PaymentStatusVoided, func(ctx context.Context, p domain.Payment) (domain.PaymentResult, error) {
+		return h.provider.Void(ctx, p.ProviderRef)
+	})
+}
+
+// RefundPayment godoc
+// @Summary      Refund a payment
+// @Description  Returns a captured payment to the customer and marks its order as refunded.
+// @Tags         payments
+// @Produce      json
+// @Param        id   path      int  true  "Payment ID"
+// @Success      200  {object}  domain.Payment
+// @Failure      400  {object}  ErrorResponse
+// @Failure      402  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Failure      503  {object}  ErrorResponse
+// @Router       /payments/{id}/refund [post]
+func (h *PaymentHandler) RefundPayment(w http.ResponseWriter, r *http.Request) {
+	h.operate(w, r, domain.PaymentStatusRefunded, func(ctx context.Context, p domain.Payment) (domain.PaymentResult, error) {
+		return h.provider.Refund(ctx, p.ProviderRef, p.Amount)
+	})
+}
+
+// Webhook godoc
+// @Summary      Receive a payment provider callback
+// @Description  Applies a payment event reported by the provider. The request must carry an X-Payment-Signature header of the form "t=<unix>,v1=<hex HMAC-SHA256 of "<t>.<body>">". Events that were already applied are acknowledged without changes.
+// @Tags         payments
+// @Accept       json
+// @Produce      json
+// @Param        X-Payment-Signature  header    string         true  "Webhook signature"
+// @Param        event                body      payment.Event  true  "Payment Event"
+// @Success      200                  {object}  domain.Payment
+// @Failure      400                  {object}  ErrorResponse
+// @Failure      401                  {object}  ErrorResponse
+// @Failure      404                  {object}  ErrorResponse
+// @Failure      409                  {object}  ErrorResponse
+// @Failure      500                  {object}  ErrorResponse
+// @Router       /payments/webhook [post]
+func (h *PaymentHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	err = payment.VerifySignature(h.webhookSecret, body, r.Header.Get(payment.SignatureHeader), h.now(), payment.DefaultSignatureTolerance)
+	if err != nil {
+		respondWithDomainError(w, err, "Unauthorized")
+		return
+	}
+
+	var event payment.Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		respondWithDecodeError(w, err)
+		return
+	}
+	to, ok := map[string]domain.PaymentStatus{
+		payment.EventCaptured: domain.PaymentStatusCaptured,
+		payment.EventVoided:   domain.PaymentStatusVoided,
+		payment.EventRefunded: domain.PaymentStatusRefunded,
+		payment.EventFailed:   domain.PaymentStatusFailed,
+	}[event.Type]
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "Unknown event type")
+		return
+	}
+	if event.Provider == "" {
+		event.Provider = h.provider.Name()
+	}
+
+	p, err := h.payments.FindByProviderRef(r.Context(), event.Provider, event.ProviderRef)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to apply payment event")
+		return
+	}
+	if p.Status == to {
+		// Providers retry webhooks; a repeated event is not an error.
+		respondWithJSON(w, http.StatusOK, p)
+		return
+	}
+	if p, err = h.payments.UpdateStatus(r.Context(), p.ID, p.Status, to, event.Reason); err != nil {
+		respondWithDomainError(w, err, "Failed to apply payment event")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, p)
+}
+
+// operate runs a provider operation on the payment named in the URL and, if
+// the provider approves, moves the payment to the state to.
+func (h *PaymentHandler) operate(w http.ResponseWriter, r *http.Request, to domain.PaymentStatus,
+	call func(ctx context.Context, p domain.Payment) (domain.PaymentResult, error)) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid payment ID")
+		return
+	}
+	p, err := h.payments.FindByID(r.Context(), id)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve payment")
+		return
+	}
+	if err := domain.CheckPaymentTransition(p.Status, to); err != nil {
+		respondWithDomainError(w, err, "Failed to update payment")
+		return
+	}
+	if to == domain.PaymentStatusCaptured {
+		order, err := h.orders.FindByID(r.Context(), p.OrderID)
+		if err != nil {
+			respondWithDomainError(w, err, "Failed to retrieve order")
+			return
+		}
+		if order.Status != domain.OrderStatusPending {
+			respondWithDomainError(w, domain.NewConflictError("only payments of pending orders can be captured", nil), "Failed to update payment")
+			return
+		}
+	}
+
+	result, err := call(r.Context(), p)
+	if err != nil {
+		respondWithDomainError(w, domain.NewUnavailableError("payment provider unavailable", err), "Failed to update payment")
+		return
+	}
+	if !result.Approved {
+		respondWithDomainError(w, domain.NewPaymentDeclinedError("payment provider declined: "+result.Reason), "Payment declined")
+		return
+	}
+	if p, err = h.payments.UpdateStatus(r.Context(), p.ID, p.Status, to, ""); err != nil {
+		respondWithDomainError(w, err, "Failed to update payment")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, p)
+}
+
+// voidAuthorized voids the authorized payments of an order, at the provider
+// and in the store, so that cancelling the order does not leave the money on
+// hold.
+func voidAuthorized(ctx context.Context, payments domain.PaymentRepository, provider domain.PaymentProvider, orderID int) error {
+	list, err := payments.FindByOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	for _, p := range list {
+		if p.Status != domain.PaymentStatusAuthorized {
+			continue
+		}
+		result, err := provider.Void(ctx, p.ProviderRef)
+		if err != nil {
+			return domain.NewUnavailableError("payment provider unavailable", err)
+		}
+		if !result.Approved {
+			return domain.NewPaymentDeclinedError("payment provider declined: " + result.Reason)
+		}
+		if _, err := payments.UpdateStatus(ctx, p.ID, p.Status, domain.PaymentStatusVoided, "order cancelled"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"e-commerce.com/internal/auth"
+	"e-commerce.com/internal/domain"
+	"e-commerce.com/internal/payment"
+	"e-commerce.com/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newPaymentFixture(t *testing.T) (http.Handler, *storage.MockOrderRepository, *storage.MockPaymentRepository) {
+	t.Helper()
+	products := &storage.MockProductRepository{Products: []domain.Product{
+		{ID: 1, Name: "Mug", Price: domain.NewMoney(1990, "BRL"), Amount: 5},
+	}}
+	orders := &storage.MockOrderRepository{Products: products}
+	for i := 0; i < 2; i++ {
+		order, err := domain.NewOrder(intPtr(1), []domain.OrderLine{{ProductID: 1, Quantity: 1}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := orders.Place(t.Context(), &order); err != nil {
+			t.Fatal(err)
+		}
+	}
+	payments := &storage.MockPaymentRepository{Orders: orders}
+	provider := payment.NewFakeProvider()
+	h := NewPaymentHandler(payments, orders, provider, []byte("whsec"))
+	orderH := NewOrderHandler(orders, &storage.MockCartRepository{}, payments, provider)
+
+	r := chi.NewRouter()
+	r.Use(OptionalAuthenticate(newTestVerifier()))
+	r.Post("/orders/{id}/payments", h.AuthorizePayment)
+	r.Post("/payments/{id}/capture", h.CapturePayment)
+	r.Post("/payments/{id}/refund", h.RefundPayment)
+	r.Post("/payments/webhook", h.Webhook)
+	r.Post("/orders/{id}/transitions", orderH.TransitionOrder)
+	return r, orders, payments
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func adminToken(t *testing.T) string {
+	return signTestToken(t, "secret", auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "99", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		Roles:            []string{auth.RoleAdmin},
+	})
+}
+
+func TestPaymentHandler_AuthorizeCaptureRefund(t *testing.T) {
+	router, orders, payments := newPaymentFixture(t)
+	customer, admin := userToken(t, "1"), adminToken(t)
+
+	tests := []struct {
+		name       string
+		token      string
+		path       string
+		body       interface{}
+		wantStatus int
+		wantOrder  domain.OrderStatus
+	}{
+		{"declined card", customer, "/orders/1/payments", AuthorizePaymentRequest{Source: payment.SourceDeclined}, http.StatusPaymentRequired, domain.OrderStatusPending},
+		{"authorize", customer, "/orders/1/payments", AuthorizePaymentRequest{Source: payment.SourceApproved}, http.StatusCreated, domain.OrderStatusPending},
+		{"authorize twice", customer, "/orders/1/payments", AuthorizePaymentRequest{Source: payment.SourceApproved}, http.StatusConflict, domain.OrderStatusPending},
+		{"failed payment cannot be captured", admin, "/payments/1/capture", nil, http.StatusConflict, domain.OrderStatusPending},
+		{"capture", admin, "/payments/2/capture", nil, http.StatusOK, domain.OrderStatusPaid},
+		{"capture twice", admin, "/payments/2/capture", nil, http.StatusConflict, domain.OrderStatusPaid},
+		{"paid order cannot be paid again", customer, "/orders/1/payments", AuthorizePaymentRequest{}, http.StatusConflict, domain.OrderStatusPaid},
+		{"refund bypassing the payment", admin, "/orders/1/transitions", TransitionRequest{Status: "refunded"}, http.StatusForbidden, domain.OrderStatusPaid},
+		{"refund", admin, "/payments/2/refund", nil, http.StatusOK, domain.OrderStatusRefunded},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := cartRequest(t, router, http.MethodPost, tc.path, tc.token, tc.body)
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tc.wantStatus, rr.Code, rr.Body.String())
+			}
+			if got := orders.Orders[0].Status; got != tc.wantOrder {
+				t.Errorf("expected the order to be %s, got %s", tc.wantOrder, got)
+			}
+		})
+	}
+
+	if got := payments.Payments[1].Status; got != domain.PaymentStatusRefunded {
+		t.Errorf("expected the payment to be refunded, got %s", got)
+	}
+	// A refund before shipping puts the mug back in stock.
+	if got := orders.Products.Products[0].Amount; got != 4 {
+		t.Errorf("expected 4 mugs in stock, got %d", got)
+	}
+}
+
+func TestPaymentHandler_Webhook(t *testing.T) {
+	router, orders, _ := newPaymentFixture(t)
+
+	rr := cartRequest(t, router, http.MethodPost, "/orders/2/payments", userToken(t, "1"), AuthorizePaymentRequest{})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("authorize: expected 201, got %d", rr.Code)
+	}
+	var p domain.Payment
+	if err := json.NewDecoder(rr.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+
+	send := func(secret string, event payment.Event) int {
+		body, _ := json.Marshal(event)
+		req := httptest.NewRequest(http.MethodPost, "/payments/webhook", bytes.NewReader(body))
+		req.Header.Set(payment.SignatureHeader, payment.Sign([]byte(secret), body, time.Now()))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	captured := payment.Event{Type: payment.EventCaptured, ProviderRef: p.ProviderRef}
+	if got := send("forged", captured); got != http.StatusUnauthorized {
+		t.Errorf("forged signature: expected 401, got %d", got)
+	}
+	if orders.Orders[1].Status != domain.OrderStatusPending {
+		t.Fatal("a forged webhook must not change the order")
+	}
+	if got := send("whsec", captured); got != http.StatusOK {
+		t.Errorf("capture: expected 200, got %d", got)
+	}
+	if got := send("whsec", captured); got != http.StatusOK {
+		t.Errorf("redelivered capture: expected 200, got %d", got)
+	}
+	if got := send("whsec", payment.Event{Type: payment.EventVoided, ProviderRef: p.ProviderRef}); got != http.StatusConflict {
+		t.Errorf("void after capture: expected 409, got %d", got)
+	}
+	if got := send("whsec", payment.Event{Type: payment.EventCaptured, ProviderRef: "unknown"}); got != http.StatusNotFound {
+		t.Errorf("unknown payment: expected 404, got %d", got)
+	}
+	if orders.Orders[1].Status != domain.OrderStatusPaid {
+		t.Errorf("expected the order to be paid, got %s", orders.Orders[1].Status)
+	}
+}
+
+func TestPaymentHandler_OrderFollowsPayment(t *testing.T) {
+	router, orders, payments := newPaymentFixture(t)
+	customer := userToken(t, "1")
+	for _, path := range []string{"/orders/1/payments", "/orders/2/payments"} {
+		if rr := cartRequest(t, router, http.MethodPost, path, customer, AuthorizePaymentRequest{}); rr.Code != http.StatusCreated {
+			t.Fatalf("authorize: expected 201, got %d", rr.Code)
+		}
+	}
+
+	if rr := cartRequest(t, router, http.MethodPost, "/orders/1/transitions", customer, TransitionRequest{Status: "cancelled"}); rr.Code != http.StatusOK {
+		t.Fatalf("cancel: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := payments.Payments[0].Status; got != domain.PaymentStatusVoided {
+		t.Errorf("expected cancelling to void the authorization, got %s", got)
+	}
+
+	// A capture reported for an order that was cancelled meanwhile cannot
+	// make it paid, so the capture is not recorded either.
+	if _, err := orders.Transition(t.Context(), 2, domain.OrderStatusCancelled, "test", ""); err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(payment.Event{Type: payment.EventCaptured, ProviderRef: payments.Payments[1].ProviderRef})
+	req := httptest.NewRequest(http.MethodPost, "/payments/webhook", bytes.NewReader(body))
+	req.Header.Set(payment.SignatureHeader, payment.Sign([]byte("whsec"), body, time.Now()))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("capture webhook: expected 409, got %d", rr.Code)
+	}
+	if got := payments.Payments[1].Status; got != domain.PaymentStatusAuthorized {
+		t.Errorf("expected the payment to stay authorized, got %s", got)
+	}
+}
@@ -2,10 +2,12 @@ package http
 
 import (
 	"encoding/json"
-	"log"
+	"fmt"
+	"io"
 	"math"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"e-commerce.com/internal/domain"
 
@@ -14,83 +16,124 @@ import (
 
 // ProductHandler Definition of the ProductHandler struct.
 type ProductHandler struct {
-	repo domain.ProductRepository
+	repo       domain.ProductRepository
+	categories domain.CategoryRepository
+	variants   domain.VariantRepository
 }
 
-// PaginatedResponse is the structure for the paginated response.
+// PaginatedResponse is the structure for the paginated response. Page mode
+// fills TotalPages and CurrentPage; cursor mode fills the cursors and, when
+// requested, Total.
 type PaginatedResponse struct {
 	Data        []domain.Product `json:"data"`
-	TotalPages  int              `json:"total_pages"`
-	CurrentPage int              `json:"current_page"`
+	TotalPages  int              `json:"total_pages,omitempty"`
+	CurrentPage int              `json:"current_page,omitempty"`
+	NextCursor  string           `json:"next_cursor,omitempty"`
+	PrevCursor  string           `json:"prev_cursor,omitempty"`
+	Total       *int             `json:"total,omitempty"`
 }
 
-// NewProductHandler creates a new instance of ProductHandler.
-func NewProductHandler(repo domain.ProductRepository) *ProductHandler {
-	return &ProductHandler{repo: repo}
-}
-
-// Aux functions
-
-func (h *ProductHandler) respondWithError(w http.ResponseWriter, code int, message string) {
-	h.respondWithJSON(w, code, map[string]string{"error": message})
-}
-
-func (h *ProductHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
-	response, err := json.Marshal(payload)
-	if err != nil {
-		log.Printf("Error marshalling JSON: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	_, _ = w.Write(response)
+// NewProductHandler creates a new instance of ProductHandler. The category
+// repository resolves the category filter of ListProducts; the variant
+// repository supplies the variant summaries of read responses.
+func NewProductHandler(repo domain.ProductRepository, categories domain.CategoryRepository, variants domain.VariantRepository) *ProductHandler {
+	return &ProductHandler{repo: repo, categories: categories, variants: variants}
 }
 
 // CreateProduct godoc
 // @Summary      Create a new product
-// @Description  Creates a new product based on the provided JSON payload. The created product, including its new ID, is returned.
+// @Description  Creates a new product based on the provided JSON payload. The created product, including its new ID and its slug (generated from the name unless given), is returned. A SKU or slug that is already taken is a 409.
 // @Tags         products
 // @Accept       json
 // @Produce      json
 // @Param        product  body      domain.Product  true  "Product Payload"
 // @Success      201      {object}  domain.Product
-// @Failure      400      {object}  map[string]string
-// @Failure      500      {object}  map[string]string
+// @Failure      400      {object}  ErrorResponse
+// @Failure      409      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
 // @Router       /products [post]
 func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	var p domain.Product
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		respondWithDecodeError(w, err)
 		return
 	}
+	p.VariantSummary, p.DeletedAt = nil, nil
 
-	if p.Name == "" || p.Price < 0 || p.Amount < 0 {
-		h.respondWithError(w, http.StatusBadRequest, "Invalid product data: name, price, and amount are required and must be valid")
+	if err := p.Validate(); err != nil {
+		respondWithDomainError(w, err, "Invalid product data")
 		return
 	}
 
-	if err := h.repo.Save(&p); err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, "Failed to create product")
-		log.Printf("Error saving product: %v", err)
+	if err := h.repo.Save(r.Context(), &p); err != nil {
+		respondWithDomainError(w, err, "Failed to create product")
 		return
 	}
 
-	h.respondWithJSON(w, http.StatusCreated, p)
+	respondWithJSON(w, http.StatusCreated, p)
 }
 
 // ListProducts godoc
 // @Summary      List all products with pagination
-// @Description  Returns a paginated list of all products.
+// @Description  Returns a paginated list of all products. Passing the cursor parameter (empty for the first page) switches from page/limit to keyset pagination, which stays consistent while products are added; follow next_cursor and prev_cursor to move between pages.
 // @Tags         products
 // @Accept       json
 // @Produce      json
-// @Param        page   query     int  false  "Page number" default(1)
-// @Param        limit  query     int  false  "Items per page" default(50)
-// @Success      200    {object}  PaginatedResponse
-// @Failure      500    {object}  map[string]string
+// @Param        page                 query     int     false  "Page number" default(1)
+// @Param        limit                query     int     false  "Items per page" default(50)
+// @Param        category             query     int     false  "Only products assigned to this category"
+// @Param        include_descendants  query     bool    false  "Also match products in subcategories of category"
+// @Param        name                 query     string  false  "Only products whose name contains this text (case-insensitive)"
+// @Param        min_price            query     string  false  "Minimum price, inclusive"
+// @Param        max_price            query     string  false  "Maximum price, inclusive"
+// @Param        in_stock             query     bool    false  "Only products with amount greater than zero"
+// @Param        sort                 query     string  false  "Sort field" Enums(id, name, price, amount) default(id)
+// @Param        order                query     string  false  "Sort direction" Enums(asc, desc) default(asc)
+// @Param        cursor               query     string  false  "Opaque cursor from next_cursor or prev_cursor"
+// @Param        include_total        query     bool    false  "In cursor mode, also return the total number of matching products"
+// @Success      200                  {object}  PaginatedResponse
+// @Failure      400                  {object}  ErrorResponse
+// @Failure      404                  {object}  ErrorResponse
+// @Failure      500                  {object}  ErrorResponse
 // @Router       /products [get]
 func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
+	page, limit := pageParams(r)
+
+	filter, err := h.productFilter(r)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve products")
+		return
+	}
+
+	if r.URL.Query().Has("cursor") {
+		h.listProductsByCursor(w, r, filter, limit)
+		return
+	}
+
+	products, total, err := h.repo.FindAll(r.Context(), filter, page, limit)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve products")
+		return
+	}
+	if err := h.attachVariantSummaries(r, products); err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve products")
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	response := PaginatedResponse{
+		Data:        products,
+		TotalPages:  totalPages,
+		CurrentPage: page,
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// pageParams reads the page and limit query parameters, falling back to the
+// first page of 50 items for missing or out-of-range values.
+func pageParams(r *http.Request) (int, int) {
 	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
 	if page < 1 {
 		page = 1
@@ -100,125 +143,481 @@ func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
 	if limit < 1 || limit > 50 {
 		limit = 50
 	}
+	return page, limit
+}
+
+// SearchResponse is one page of full-text search results.
+type SearchResponse struct {
+	Data        []domain.ProductSearchHit `json:"data"`
+	Total       int                       `json:"total"`
+	TotalPages  int                       `json:"total_pages"`
+	CurrentPage int                       `json:"current_page"`
+}
 
-	products, total, err := h.repo.FindAll(page, limit)
+// SearchProducts godoc
+// @Summary      Search products
+// @Description  Full-text search over product names and descriptions, best match first. Name matches rank above description matches. The query accepts quoted phrases, "or" and -excluded words. Each hit carries an HTML snippet with the matched words wrapped in <mark>.
+// @Tags         products
+// @Produce      json
+// @Param        q      query     string  true   "Search query"
+// @Param        page   query     int     false  "Page number" default(1)
+// @Param        limit  query     int     false  "Items per page" default(50)
+// @Success      200    {object}  SearchResponse
+// @Failure      400    {object}  ErrorResponse
+// @Failure      500    {object}  ErrorResponse
+// @Router       /products/search [get]
+func (h *ProductHandler) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing search query: q is required")
+		return
+	}
+	page, limit := pageParams(r)
+
+	hits, total, err := h.repo.Search(r.Context(), query, page, limit)
 	if err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, "Failed to retrieve products")
-		log.Printf("Error finding all products: %v", err)
+		respondWithDomainError(w, err, "Failed to search products")
 		return
 	}
 
-	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+	products := make([]domain.Product, len(hits))
+	for i, hit := range hits {
+		products[i] = hit.Product
+	}
+	if err := h.attachVariantSummaries(r, products); err != nil {
+		respondWithDomainError(w, err, "Failed to search products")
+		return
+	}
+	for i := range hits {
+		hits[i].Product = products[i]
+	}
+	if hits == nil {
+		hits = []domain.ProductSearchHit{}
+	}
 
-	response := PaginatedResponse{
-		Data:        products,
-		TotalPages:  totalPages,
+	respondWithJSON(w, http.StatusOK, SearchResponse{
+		Data:        hits,
+		Total:       total,
+		TotalPages:  int(math.Ceil(float64(total) / float64(limit))),
 		CurrentPage: page,
+	})
+}
+
+// Suggestion limits: typeahead needs only a handful of names per keystroke.
+const (
+	defaultSuggestLimit = 10
+	maxSuggestLimit     = 20
+	maxSuggestPrefix    = 100
+)
+
+// SuggestProducts godoc
+// @Summary      Suggest product names
+// @Description  Returns up to limit name completions for search-as-you-type: names starting with prefix first, then names containing a word close to it, so small typos still match.
+// @Tags         products
+// @Produce      json
+// @Param        prefix  query     string  true   "Text typed so far"
+// @Param        limit   query     int     false  "Maximum number of suggestions" default(10) maximum(20)
+// @Success      200     {array}   domain.ProductSuggestion
+// @Failure      400     {object}  ErrorResponse
+// @Failure      500     {object}  ErrorResponse
+// @Router       /products/suggest [get]
+func (h *ProductHandler) SuggestProducts(w http.ResponseWriter, r *http.Request) {
+	prefix := strings.TrimSpace(r.URL.Query().Get("prefix"))
+	if prefix == "" || len(prefix) > maxSuggestPrefix {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid prefix: must be 1 to %d characters", maxSuggestPrefix))
+		return
 	}
 
-	h.respondWithJSON(w, http.StatusOK, response)
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 {
+		limit = defaultSuggestLimit
+	}
+	if limit > maxSuggestLimit {
+		limit = maxSuggestLimit
+	}
+
+	suggestions, err := h.repo.Suggest(r.Context(), prefix, limit)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to suggest products")
+		return
+	}
+	if suggestions == nil {
+		suggestions = []domain.ProductSuggestion{}
+	}
+
+	// Clients send a request per keystroke; let them reuse recent answers.
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	respondWithJSON(w, http.StatusOK, suggestions)
+}
+
+// listProductsByCursor serves ListProducts in keyset pagination mode.
+func (h *ProductHandler) listProductsByCursor(w http.ResponseWriter, r *http.Request, filter domain.ProductFilter, limit int) {
+	var cursor *domain.ProductCursor
+	if raw := r.URL.Query().Get("cursor"); raw != "" {
+		decoded, err := domain.DecodeProductCursor(raw, filter.Sort)
+		if err != nil {
+			respondWithDomainError(w, err, "Invalid cursor")
+			return
+		}
+		cursor = &decoded
+	}
+
+	page, err := h.repo.FindPage(r.Context(), filter, cursor, limit)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve products")
+		return
+	}
+	if err := h.attachVariantSummaries(r, page.Products); err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve products")
+		return
+	}
+
+	response := PaginatedResponse{Data: page.Products}
+	if response.Data == nil {
+		response.Data = []domain.Product{}
+	}
+	if n := len(page.Products); n > 0 {
+		if page.HasNext {
+			response.NextCursor = domain.NewProductCursor(page.Products[n-1], filter.Sort, false).Encode()
+		}
+		if page.HasPrev {
+			response.PrevCursor = domain.NewProductCursor(page.Products[0], filter.Sort, true).Encode()
+		}
+	}
+
+	if includeTotal, _ := strconv.ParseBool(r.URL.Query().Get("include_total")); includeTotal {
+		total, err := h.repo.Count(r.Context(), filter)
+		if err != nil {
+			respondWithDomainError(w, err, "Failed to count products")
+			return
+		}
+		response.Total = &total
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// attachVariantSummaries fills in the variant summary of every product that
+// has variants, with a single repository call for the whole page.
+func (h *ProductHandler) attachVariantSummaries(r *http.Request, products []domain.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+	ids := make([]int, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	summaries, err := h.variants.Summaries(r.Context(), ids)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		if summary, ok := summaries[products[i].ID]; ok {
+			products[i].VariantSummary = &summary
+		}
+	}
+	return nil
+}
+
+// productFilter builds the repository filter from the list query parameters.
+func (h *ProductHandler) productFilter(r *http.Request) (domain.ProductFilter, error) {
+	var filter domain.ProductFilter
+	query := r.URL.Query()
+
+	if raw := query.Get("category"); raw != "" {
+		categoryID, err := strconv.Atoi(raw)
+		if err != nil {
+			return filter, domain.NewValidationError("Invalid category ID")
+		}
+		filter.CategoryIDs = []int{categoryID}
+		if descendants, _ := strconv.ParseBool(query.Get("include_descendants")); descendants {
+			ids, err := h.categories.Descendants(r.Context(), categoryID)
+			if err != nil {
+				return filter, err
+			}
+			filter.CategoryIDs = ids
+		}
+	}
+
+	filter.Name = query.Get("name")
+
+	var err error
+	if filter.MinPrice, err = parsePriceParam(query.Get("min_price")); err != nil {
+		return filter, err
+	}
+	if filter.MaxPrice, err = parsePriceParam(query.Get("max_price")); err != nil {
+		return filter, err
+	}
+
+	if raw := query.Get("in_stock"); raw != "" {
+		inStock, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, domain.NewValidationError("Invalid in_stock value: must be true or false")
+		}
+		filter.InStock = inStock
+	}
+
+	sort, err := domain.ParseProductSort(query.Get("sort"), query.Get("order"))
+	if err != nil {
+		return filter, err
+	}
+	filter.Sort = sort
+
+	return filter, nil
+}
+
+// parsePriceParam parses an optional price query parameter; empty means unset.
+func parsePriceParam(raw string) (*domain.Money, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	price, err := domain.ParseMoney(raw, domain.DefaultCurrency)
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
 }
 
 // GetProduct godoc
 // @Summary      Get a product by ID
-// @Description  Retrieves the details of a specific product by its unique ID.
+// @Description  Retrieves the details of a specific product by its unique ID. The ETag header carries the product version; sending it back in If-None-Match answers 304 Not Modified while the product is unchanged.
 // @Tags         products
 // @Accept       json
 // @Produce      json
-// @Param        id   path      int  true  "Product ID"
-// @Success      200  {object}  domain.Product
-// @Failure      400  {object}  map[string]string
-// @Failure      404  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Param        id             path      int     true   "Product ID"
+// @Param        If-None-Match  header    string  false  "ETag from a previous read"
+// @Success      200            {object}  domain.Product
+// @Header       200            {string}  ETag  "Product version"
+// @Success      304            "Not modified"
+// @Failure      400            {object}  ErrorResponse
+// @Failure      404            {object}  ErrorResponse
+// @Failure      500            {object}  ErrorResponse
 // @Router       /products/{id} [get]
 func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID")
 		return
 	}
 
-	product, err := h.repo.FindByID(id)
+	product, err := h.repo.FindByID(r.Context(), id)
+	h.respondWithProduct(w, r, product, err)
+}
+
+// GetProductBySKU godoc
+// @Summary      Get a product by SKU
+// @Description  Retrieves a product by its stock keeping unit, for warehouse and other integrations. Supports ETag and If-None-Match like GET /products/{id}.
+// @Tags         products
+// @Produce      json
+// @Param        sku  path      string  true  "Product SKU"
+// @Success      200  {object}  domain.Product
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /products/by-sku/{sku} [get]
+func (h *ProductHandler) GetProductBySKU(w http.ResponseWriter, r *http.Request) {
+	product, err := h.repo.FindBySKU(r.Context(), chi.URLParam(r, "sku"))
+	h.respondWithProduct(w, r, product, err)
+}
+
+// GetProductBySlug godoc
+// @Summary      Get a product by slug
+// @Description  Retrieves a product by the URL slug generated from its name. Supports ETag and If-None-Match like GET /products/{id}.
+// @Tags         products
+// @Produce      json
+// @Param        slug  path      string  true  "Product slug"
+// @Success      200   {object}  domain.Product
+// @Failure      404   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Router       /products/by-slug/{slug} [get]
+func (h *ProductHandler) GetProductBySlug(w http.ResponseWriter, r *http.Request) {
+	product, err := h.repo.FindBySlug(r.Context(), chi.URLParam(r, "slug"))
+	h.respondWithProduct(w, r, product, err)
+}
+
+// respondWithProduct writes a product looked up by one of the Get handlers,
+// together with its variant summary, or the lookup error.
+func (h *ProductHandler) respondWithProduct(w http.ResponseWriter, r *http.Request, product domain.Product, err error) {
 	if err != nil {
-		if err.Error() == "product not found" {
-			h.respondWithError(w, http.StatusNotFound, err.Error())
-		} else {
-			h.respondWithError(w, http.StatusInternalServerError, "Failed to retrieve product")
-			log.Printf("Error finding product by ID: %v", err)
-		}
+		respondWithDomainError(w, err, "Failed to retrieve product")
+		return
+	}
+	etag := productETag(product.Version)
+	w.Header().Set("ETag", etag)
+	if notModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
-	h.respondWithJSON(w, http.StatusOK, product)
+
+	products := []domain.Product{product}
+	if err := h.attachVariantSummaries(r, products); err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve product")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, products[0])
 }
 
 // UpdateProduct godoc
 // @Summary      Update an existing product
-// @Description  Updates the details of an existing product identified by its ID using the provided JSON payload.
+// @Description  Updates the details of an existing product identified by its ID using the provided JSON payload. Omitting the slug keeps the current one. With If-Match, the update only applies if the product still has that ETag.
 // @Tags         products
 // @Accept       json
 // @Produce      json
-// @Param        id       path      int             true  "Product ID"
-// @Param        product  body      domain.Product  true  "Product Payload"
-// @Success      200      {object}  domain.Product
-// @Failure      400      {object}  map[string]string
-// @Failure      404      {object}  map[string]string
-// @Failure      500      {object}  map[string]string
+// @Param        id        path      int             true   "Product ID"
+// @Param        If-Match  header    string          false  "ETag the product must still have"
+// @Param        product   body      domain.Product  true   "Product Payload"
+// @Success      200       {object}  domain.Product
+// @Header       200       {string}  ETag  "New product version"
+// @Failure      400       {object}  ErrorResponse
+// @Failure      404       {object}  ErrorResponse
+// @Failure      409       {object}  ErrorResponse
+// @Failure      412       {object}  ErrorResponse
+// @Failure      500       {object}  ErrorResponse
 // @Router       /products/{id} [put]
 func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID")
 		return
 	}
 
 	var p domain.Product
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		respondWithDecodeError(w, err)
 		return
 	}
 
 	p.ID = id
-	if err := h.repo.Update(&p); err != nil {
-		if err.Error() == "product not found for update" {
-			h.respondWithError(w, http.StatusNotFound, err.Error())
-		} else {
-			h.respondWithError(w, http.StatusInternalServerError, "Failed to update product")
-			log.Printf("Error updating product: %v", err)
-		}
+	p.VariantSummary, p.DeletedAt = nil, nil
+	if err := p.Validate(); err != nil {
+		respondWithDomainError(w, err, "Invalid product data")
+		return
+	}
+
+	if p.Version, err = h.expectedVersion(r, id); err != nil {
+		respondWithDomainError(w, err, "Failed to update product")
+		return
+	}
+	if err := h.repo.Update(r.Context(), &p); err != nil {
+		respondWithDomainError(w, err, "Failed to update product")
 		return
 	}
 
-	h.respondWithJSON(w, http.StatusOK, p)
+	w.Header().Set("ETag", productETag(p.Version))
+	respondWithJSON(w, http.StatusOK, p)
+}
+
+// PatchProduct godoc
+// @Summary      Partially update a product
+// @Description  Applies an RFC 7396 JSON merge patch to a product: only the fields present in the body change, and null removes optional fields such as sku. Nested objects merge, so {"price": {"amount": "9.90"}} keeps the currency, as does a bare {"price": "9.90"}. The merged product must pass the same validation as a full update. With If-Match, the patch only applies if the product still has that ETag.
+// @Tags         products
+// @Accept       application/merge-patch+json
+// @Produce      json
+// @Param        id        path      int     true   "Product ID"
+// @Param        If-Match  header    string  false  "ETag the product must still have"
+// @Param        patch     body      object  true   "Merge patch"
+// @Success      200       {object}  domain.Product
+// @Header       200       {string}  ETag  "New product version"
+// @Failure      400       {object}  ErrorResponse
+// @Failure      404       {object}  ErrorResponse
+// @Failure      409       {object}  ErrorResponse
+// @Failure      412       {object}  ErrorResponse
+// @Failure      415       {object}  ErrorResponse
+// @Failure      500       {object}  ErrorResponse
+// @Router       /products/{id} [patch]
+func (h *ProductHandler) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+	if !isMergePatch(r) {
+		respondWithError(w, http.StatusUnsupportedMediaType, "Unsupported patch format: use "+mergePatchMediaType)
+		return
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondWithDecodeError(w, err)
+		return
+	}
+	if patch, err = keepPriceCurrency(patch); err != nil {
+		respondWithDecodeError(w, err)
+		return
+	}
+
+	current, err := h.repo.FindByID(r.Context(), id)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve product")
+		return
+	}
+	if !matchesVersion(r, current.Version) {
+		respondWithDomainError(w, errVersionMismatch(), "Failed to update product")
+		return
+	}
+	doc, err := json.Marshal(current)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to update product")
+		return
+	}
+	merged, err := applyMergePatch(doc, patch)
+	if err != nil {
+		respondWithDecodeError(w, err)
+		return
+	}
+
+	var p domain.Product
+	if err := json.Unmarshal(merged, &p); err != nil {
+		respondWithDecodeError(w, err)
+		return
+	}
+	p.ID = id
+	p.VariantSummary, p.DeletedAt = nil, nil
+	if err := p.Validate(); err != nil {
+		respondWithDomainError(w, err, "Invalid product data")
+		return
+	}
+
+	// Writing against the version the patch was applied to keeps a
+	// concurrent change from being overwritten with stale fields.
+	p.Version = current.Version
+	if err := h.repo.Update(r.Context(), &p); err != nil {
+		respondWithDomainError(w, err, "Failed to update product")
+		return
+	}
+
+	w.Header().Set("ETag", productETag(p.Version))
+	respondWithJSON(w, http.StatusOK, p)
 }
 
 // DeleteProduct godoc
 // @Summary      Delete a product
-// @Description  Deletes a product from the database by its unique ID.
+// @Description  Moves a product to the trash. It disappears from the catalog but can be restored with POST /products/{id}/restore until it is purged after the retention period. With If-Match, the product is only deleted if it still has that ETag.
 // @Tags         products
 // @Accept       json
 // @Produce      json
-// @Param        id   path      int  true  "Product ID"
-// @Success      200  {object}  map[string]string
-// @Failure      400  {object}  map[string]string
-// @Failure      404  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Param        id        path      int     true   "Product ID"
+// @Param        If-Match  header    string  false  "ETag the product must still have"
+// @Success      200       {object}  map[string]string
+// @Failure      400       {object}  ErrorResponse
+// @Failure      404       {object}  ErrorResponse
+// @Failure      412       {object}  ErrorResponse
+// @Failure      500       {object}  ErrorResponse
 // @Router       /products/{id} [delete]
 func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID")
 		return
 	}
 
-	if err := h.repo.Delete(id); err != nil {
-		if err.Error() == "product not found for deletion" {
-			h.respondWithError(w, http.StatusNotFound, err.Error())
-		} else {
-			h.respondWithError(w, http.StatusInternalServerError, "Failed to delete product")
-			log.Printf("Error deleting product: %v", err)
-		}
+	version, err := h.expectedVersion(r, id)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to delete product")
+		return
+	}
+	if err := h.repo.Delete(r.Context(), id, version); err != nil {
+		respondWithDomainError(w, err, "Failed to delete product")
 		return
 	}
 
-	h.respondWithJSON(w, http.StatusOK, map[string]string{"message": "Product deleted successfully"})
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Product deleted successfully"})
 }
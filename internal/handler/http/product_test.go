@@ -1,6 +1,10 @@
 package http
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -8,6 +12,8 @@ import (
 
 	"e-commerce.com/internal/domain"
 	"e-commerce.com/internal/storage"
+
+	"github.com/go-chi/chi/v5"
 )
 
 func TestListProductsHandler(t *testing.T) {
@@ -15,10 +21,10 @@ func TestListProductsHandler(t *testing.T) {
 	// The mock with predefined data.
 	mockRepo := &storage.MockProductRepository{
 		Products: []domain.Product{
-			{ID: 1, Name: "Test Product", Price: 10.0, Amount: 5, Description: "A test product"},
+			{ID: 1, Name: "Test Product", Price: domain.NewMoney(1000, "BRL"), Amount: 5, Description: "A test product"},
 		},
 	}
-	productHandler := NewProductHandler(mockRepo)
+	productHandler := NewProductHandler(mockRepo, &storage.MockCategoryRepository{}, &storage.MockVariantRepository{})
 
 	// The request HTTP test.
 	req, err := http.NewRequest("GET", "/products", nil)
@@ -41,9 +47,510 @@ func TestListProductsHandler(t *testing.T) {
 	}
 
 	// Check if the expected data is in the response body.
-	expected := `{"data":[{"id":1,"name":"Test Product","price":10,"amount":5,"description":"A test product"}],"total_pages":1,"current_page":1}`
+	expected := `{"data":[{"id":1,"name":"Test Product","price":{"amount":"10.00","currency":"BRL"},"amount":5,"description":"A test product"}],"total_pages":1,"current_page":1}`
+
+	if strings.TrimSpace(rr.Body.String()) != expected {
+		t.Errorf("handler returned unexpected body: got %v want %v", rr.Body.String(), expected)
+	}
+}
+
+func TestGetProductHandler_NotFound(t *testing.T) {
+	mockRepo := &storage.MockProductRepository{}
+	productHandler := NewProductHandler(mockRepo, &storage.MockCategoryRepository{}, &storage.MockVariantRepository{})
+
+	req := httptest.NewRequest("GET", "/products/42", nil)
+	req = withURLParam(req, "id", "42")
+	rr := httptest.NewRecorder()
 
+	productHandler.GetProduct(rr, req)
+
+	if status := rr.Code; status != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", status, http.StatusNotFound)
+	}
+
+	expected := `{"error":"product not found","code":"not_found"}`
 	if strings.TrimSpace(rr.Body.String()) != expected {
 		t.Errorf("handler returned unexpected body: got %v want %v", rr.Body.String(), expected)
 	}
 }
+
+func TestDeleteProductHandler_ErrorKinds(t *testing.T) {
+	tests := []struct {
+		name       string
+		repoErr    error
+		wantStatus int
+	}{
+		{"not found", domain.NewNotFoundError("product not found"), http.StatusNotFound},
+		{"conflict", domain.NewConflictError("product is referenced", nil), http.StatusConflict},
+		{"unavailable", domain.NewUnavailableError("database unavailable", errors.New("dial tcp")), http.StatusServiceUnavailable},
+		{"unclassified", errors.New("boom"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			productHandler := NewProductHandler(&storage.MockProductRepository{Error: tt.repoErr}, &storage.MockCategoryRepository{}, &storage.MockVariantRepository{})
+
+			req := httptest.NewRequest("DELETE", "/products/1", nil)
+			req = withURLParam(req, "id", "1")
+			rr := httptest.NewRecorder()
+
+			productHandler.DeleteProduct(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.wantStatus)
+			}
+			if strings.Contains(rr.Body.String(), "dial tcp") || strings.Contains(rr.Body.String(), "boom") {
+				t.Errorf("handler leaked the underlying error: %v", rr.Body.String())
+			}
+		})
+	}
+}
+
+// withURLParam attaches a chi URL parameter to the request, as the router would.
+func withURLParam(r *http.Request, key, value string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(key, value)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestListProductsHandler_CategoryFilter(t *testing.T) {
+	parent := 1
+	products := &storage.MockProductRepository{
+		Products: []domain.Product{
+			{ID: 1, Name: "Laptop", Price: domain.NewMoney(500000, "BRL"), Amount: 1},
+			{ID: 2, Name: "Mouse", Price: domain.NewMoney(5000, "BRL"), Amount: 1},
+			{ID: 3, Name: "Chair", Price: domain.NewMoney(30000, "BRL"), Amount: 1},
+		},
+	}
+	categories := &storage.MockCategoryRepository{
+		Categories: []domain.Category{
+			{ID: 1, Name: "Electronics"},
+			{ID: 2, Name: "Accessories", ParentID: &parent},
+			{ID: 3, Name: "Furniture"},
+		},
+		Products: products,
+	}
+	ctx := context.Background()
+	for categoryID, productID := range map[int]int{1: 1, 2: 2, 3: 3} {
+		if err := categories.AssignProduct(ctx, categoryID, productID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	productHandler := NewProductHandler(products, categories, &storage.MockVariantRepository{})
+
+	tests := []struct {
+		query   string
+		wantIDs []int
+	}{
+		{"?category=1", []int{1}},
+		{"?category=1&include_descendants=true", []int{1, 2}},
+		{"?category=3&include_descendants=true", []int{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/products"+tt.query, nil)
+			rr := httptest.NewRecorder()
+
+			productHandler.ListProducts(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+			}
+			var resp PaginatedResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			var gotIDs []int
+			for _, p := range resp.Data {
+				gotIDs = append(gotIDs, p.ID)
+			}
+			if fmt.Sprint(gotIDs) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("got products %v, want %v", gotIDs, tt.wantIDs)
+			}
+		})
+	}
+
+	t.Run("unknown category", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/products?category=99&include_descendants=true", nil)
+		rr := httptest.NewRecorder()
+
+		productHandler.ListProducts(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+}
+
+func TestListProductsHandler_FilterAndSort(t *testing.T) {
+	mockRepo := &storage.MockProductRepository{
+		Products: []domain.Product{
+			{ID: 1, Name: "Gaming Mouse", Price: domain.NewMoney(19990, "BRL"), Amount: 5},
+			{ID: 2, Name: "Office Mouse", Price: domain.NewMoney(4990, "BRL"), Amount: 0},
+			{ID: 3, Name: "Keyboard", Price: domain.NewMoney(24990, "BRL"), Amount: 2},
+			{ID: 4, Name: "Mouse Pad", Price: domain.NewMoney(4990, "BRL"), Amount: 10},
+		},
+	}
+	productHandler := NewProductHandler(mockRepo, &storage.MockCategoryRepository{}, &storage.MockVariantRepository{})
+
+	tests := []struct {
+		query   string
+		wantIDs []int
+	}{
+		{"?name=mouse", []int{1, 2, 4}},
+		{"?min_price=49.90&max_price=199.90", []int{1, 2, 4}},
+		{"?max_price=49.9", []int{2, 4}},
+		{"?in_stock=true", []int{1, 3, 4}},
+		{"?sort=price", []int{2, 4, 1, 3}},
+		{"?sort=price&order=desc", []int{3, 1, 4, 2}},
+		{"?sort=name", []int{1, 3, 4, 2}},
+		{"?name=mouse&in_stock=true&sort=amount&order=desc", []int{4, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/products"+tt.query, nil)
+			rr := httptest.NewRecorder()
+
+			productHandler.ListProducts(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+			}
+			var resp PaginatedResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			var gotIDs []int
+			for _, p := range resp.Data {
+				gotIDs = append(gotIDs, p.ID)
+			}
+			if fmt.Sprint(gotIDs) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("got products %v, want %v", gotIDs, tt.wantIDs)
+			}
+		})
+	}
+
+	for _, query := range []string{"?sort=description", "?order=up", "?min_price=abc", "?in_stock=maybe"} {
+		t.Run(query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/products"+query, nil)
+			rr := httptest.NewRecorder()
+
+			productHandler.ListProducts(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestListProductsHandler_CursorPagination(t *testing.T) {
+	mockRepo := &storage.MockProductRepository{}
+	for i := 1; i <= 5; i++ {
+		mockRepo.Products = append(mockRepo.Products, domain.Product{
+			ID: i, Name: fmt.Sprintf("Product %d", i), Price: domain.NewMoney(int64(1000*(6-i)), "BRL"), Amount: 1,
+		})
+	}
+	productHandler := NewProductHandler(mockRepo, &storage.MockCategoryRepository{}, &storage.MockVariantRepository{})
+
+	list := func(query string) PaginatedResponse {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/products?sort=price&limit=2&"+query, nil)
+		rr := httptest.NewRecorder()
+		productHandler.ListProducts(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, http.StatusOK, rr.Body.String())
+		}
+		var resp PaginatedResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	ids := func(resp PaginatedResponse) string {
+		var got []int
+		for _, p := range resp.Data {
+			got = append(got, p.ID)
+		}
+		return fmt.Sprint(got)
+	}
+
+	first := list("cursor=&include_total=true")
+	if ids(first) != "[5 4]" || first.PrevCursor != "" || first.NextCursor == "" {
+		t.Fatalf("unexpected first page: %+v", first)
+	}
+	if first.Total == nil || *first.Total != 5 {
+		t.Errorf("expected total 5, got %v", first.Total)
+	}
+
+	// A product inserted before the current position must not shift the next page.
+	mockRepo.Products = append(mockRepo.Products, domain.Product{ID: 6, Name: "Cheap", Price: domain.NewMoney(500, "BRL"), Amount: 1})
+
+	second := list("cursor=" + first.NextCursor)
+	if ids(second) != "[3 2]" || second.PrevCursor == "" || second.NextCursor == "" {
+		t.Fatalf("unexpected second page: %+v", second)
+	}
+	if second.Total != nil {
+		t.Errorf("expected no total unless requested, got %v", *second.Total)
+	}
+
+	third := list("cursor=" + second.NextCursor)
+	if ids(third) != "[1]" || third.NextCursor != "" {
+		t.Fatalf("unexpected third page: %+v", third)
+	}
+
+	// Going back now also reveals the product inserted before the first page.
+	back := list("cursor=" + second.PrevCursor)
+	if ids(back) != "[5 4]" || back.NextCursor == "" || back.PrevCursor == "" {
+		t.Fatalf("unexpected previous page: %+v", back)
+	}
+
+	req := httptest.NewRequest("GET", "/products?sort=name&cursor="+first.NextCursor, nil)
+	rr := httptest.NewRecorder()
+	productHandler.ListProducts(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected a cursor issued for another sort to be rejected, got %v", rr.Code)
+	}
+}
+
+func TestProductHandler_SKUAndSlug(t *testing.T) {
+	products := &storage.MockProductRepository{}
+	h := NewProductHandler(products, &storage.MockCategoryRepository{}, &storage.MockVariantRepository{})
+	router := chi.NewRouter()
+	router.Post("/products", h.CreateProduct)
+	router.Put("/products/{id}", h.UpdateProduct)
+	router.Get("/products/by-sku/{sku}", h.GetProductBySKU)
+	router.Get("/products/by-slug/{slug}", h.GetProductBySlug)
+
+	create := func(body map[string]interface{}) (*httptest.ResponseRecorder, domain.Product) {
+		rr := cartRequest(t, router, "POST", "/products", "", body)
+		var p domain.Product
+		if rr.Code == http.StatusCreated {
+			if err := json.NewDecoder(rr.Body).Decode(&p); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rr, p
+	}
+	mug := map[string]interface{}{"name": "Caneca Térmica", "price": "39.90", "amount": 3, "sku": "MUG-001"}
+	rr, first := create(mug)
+	if rr.Code != http.StatusCreated || first.Slug != "caneca-termica" {
+		t.Fatalf("expected 201 with slug caneca-termica, got %d %q", rr.Code, first.Slug)
+	}
+	if rr, _ := create(mug); rr.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a duplicate SKU, got %d", rr.Code)
+	}
+	mug["sku"] = "MUG-002"
+	if _, second := create(mug); second.Slug != "caneca-termica-2" {
+		t.Errorf("expected a suffixed slug for a duplicate name, got %q", second.Slug)
+	}
+	if rr, _ := create(map[string]interface{}{"name": "Other", "price": "1.00", "amount": 1, "slug": "caneca-termica"}); rr.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a taken slug, got %d", rr.Code)
+	}
+
+	// An update without a slug keeps the current one.
+	update := map[string]interface{}{"name": "Caneca Nova", "price": "42.00", "amount": 3, "sku": "MUG-001"}
+	if rr := cartRequest(t, router, "PUT", "/products/1", "", update); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 updating, got %d: %s", rr.Code, rr.Body)
+	}
+
+	for _, path := range []string{"/products/by-sku/MUG-001", "/products/by-slug/caneca-termica"} {
+		rr := cartRequest(t, router, "GET", path, "", nil)
+		var p domain.Product
+		if err := json.NewDecoder(rr.Body).Decode(&p); err != nil {
+			t.Fatal(err)
+		}
+		if rr.Code != http.StatusOK || p.ID != 1 || p.Name != "Caneca Nova" {
+			t.Errorf("GET %s: expected product 1, got %d %+v", path, rr.Code, p)
+		}
+	}
+	if rr := cartRequest(t, router, "GET", "/products/by-slug/missing", "", nil); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown slug, got %d", rr.Code)
+	}
+}
+
+func TestSearchProductsHandler(t *testing.T) {
+	products := &storage.MockProductRepository{Products: []domain.Product{
+		{ID: 1, Name: "Coffee Mug", Price: domain.NewMoney(1990, "BRL"), Description: "Ceramic mug"},
+		{ID: 2, Name: "Espresso Cup", Price: domain.NewMoney(990, "BRL"), Description: "Small <b>coffee</b> cup"},
+		{ID: 3, Name: "Tea Pot", Price: domain.NewMoney(4990, "BRL"), Description: "Glass pot"},
+	}}
+	h := NewProductHandler(products, &storage.MockCategoryRepository{}, &storage.MockVariantRepository{})
+
+	rr := httptest.NewRecorder()
+	h.SearchProducts(rr, httptest.NewRequest("GET", "/products/search?q=Coffee", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body)
+	}
+	var response SearchResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Total != 2 || len(response.Data) != 2 {
+		t.Fatalf("expected 2 hits, got %+v", response)
+	}
+	if response.Data[0].ID != 1 || response.Data[1].ID != 2 {
+		t.Errorf("expected the name match to rank first, got %d then %d", response.Data[0].ID, response.Data[1].ID)
+	}
+	want := "Espresso Cup. Small &lt;b&gt;<mark>coffee</mark>&lt;/b&gt; cup"
+	if response.Data[1].Snippet != want {
+		t.Errorf("expected an escaped, highlighted snippet %q, got %q", want, response.Data[1].Snippet)
+	}
+
+	rr = httptest.NewRecorder()
+	h.SearchProducts(rr, httptest.NewRequest("GET", "/products/search?q=+", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an empty query, got %d", rr.Code)
+	}
+}
+
+func TestSuggestProductsHandler(t *testing.T) {
+	products := &storage.MockProductRepository{Products: []domain.Product{
+		{ID: 1, Name: "Keyboard Cover", Slug: "keyboard-cover"},
+		{ID: 2, Name: "Keyboard", Slug: "keyboard"},
+		{ID: 3, Name: "Mechanical Keyboard", Slug: "mechanical-keyboard"},
+		{ID: 4, Name: "Mouse", Slug: "mouse"},
+	}}
+	h := NewProductHandler(products, &storage.MockCategoryRepository{}, &storage.MockVariantRepository{})
+
+	suggest := func(query string) []domain.ProductSuggestion {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		h.SuggestProducts(rr, httptest.NewRequest("GET", "/products/suggest?"+query, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", query, rr.Code, rr.Body)
+		}
+		var suggestions []domain.ProductSuggestion
+		if err := json.NewDecoder(rr.Body).Decode(&suggestions); err != nil {
+			t.Fatal(err)
+		}
+		return suggestions
+	}
+	ids := func(suggestions []domain.ProductSuggestion) []int {
+		var ids []int
+		for _, s := range suggestions {
+			ids = append(ids, s.ID)
+		}
+		return ids
+	}
+
+	if got := ids(suggest("prefix=key")); fmt.Sprint(got) != "[2 1 3]" {
+		t.Errorf("expected prefix matches before word matches, got %v", got)
+	}
+	if got := ids(suggest("prefix=keyborad")); fmt.Sprint(got) != "[2 1 3]" {
+		t.Errorf("expected a typo to still match, got %v", got)
+	}
+	if got := ids(suggest("prefix=key&limit=1")); fmt.Sprint(got) != "[2]" {
+		t.Errorf("expected the limit to apply, got %v", got)
+	}
+
+	rr := httptest.NewRecorder()
+	h.SuggestProducts(rr, httptest.NewRequest("GET", "/products/suggest", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a prefix, got %d", rr.Code)
+	}
+}
+
+func TestPatchProductHandler(t *testing.T) {
+	products := &storage.MockProductRepository{Products: []domain.Product{
+		{ID: 1, Name: "Mouse", Price: domain.NewMoney(19990, "USD"), Amount: 9, Description: "RGB mouse", SKU: "MOUSE-1", Slug: "mouse"},
+	}}
+	h := NewProductHandler(products, &storage.MockCategoryRepository{}, &storage.MockVariantRepository{})
+
+	patch := func(contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PATCH", "/products/1", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+		h.PatchProduct(rr, withURLParam(req, "id", "1"))
+		return rr
+	}
+
+	rr := patch("application/merge-patch+json", `{"price": {"amount": "9.90"}, "sku": null}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body)
+	}
+	got := products.Products[0]
+	want := domain.Product{ID: 1, Name: "Mouse", Price: domain.NewMoney(990, "USD"), Amount: 9, Description: "RGB mouse", Slug: "mouse", Version: 1}
+	if got != want {
+		t.Errorf("expected only the patched fields to change:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	// A bare amount keeps the current currency instead of the default one.
+	if rr := patch("application/json", `{"price": 12.50}`); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a bare price, got %d: %s", rr.Code, rr.Body)
+	}
+	if got := products.Products[0].Price; got != domain.NewMoney(1250, "USD") {
+		t.Errorf("expected the price to stay in USD, got %+v", got)
+	}
+	if rr := patch("application/json", `{"price": "9.90"}`); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a bare price, got %d: %s", rr.Code, rr.Body)
+	}
+	want.Version = 3
+
+	if rr := patch("application/json", `{"amount": -1}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 when the merged product is invalid, got %d", rr.Code)
+	}
+	if rr := patch("application/json-patch+json", `[{"op": "remove", "path": "/sku"}]`); rr.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected 415 for a JSON Patch document, got %d", rr.Code)
+	}
+	if products.Products[0] != want {
+		t.Errorf("expected rejected patches to leave the product unchanged, got %+v", products.Products[0])
+	}
+}
+
+func TestProductHandler_ETags(t *testing.T) {
+	products := &storage.MockProductRepository{Products: []domain.Product{
+		{ID: 1, Name: "Mouse", Price: domain.NewMoney(19990, "BRL"), Amount: 9, Slug: "mouse", Version: 3},
+	}}
+	h := NewProductHandler(products, &storage.MockCategoryRepository{}, &storage.MockVariantRepository{})
+	router := chi.NewRouter()
+	router.Get("/products/{id}", h.GetProduct)
+	router.Put("/products/{id}", h.UpdateProduct)
+	router.Patch("/products/{id}", h.PatchProduct)
+	router.Delete("/products/{id}", h.DeleteProduct)
+
+	send := func(method, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/products/1", strings.NewReader(body))
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := send("GET", "", nil)
+	if rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"3"` {
+		t.Fatalf("expected 200 with ETag \"3\", got %d %q", rr.Code, rr.Header().Get("ETag"))
+	}
+	if rr := send("GET", "", map[string]string{"If-None-Match": `W/"3"`}); rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Errorf("expected an empty 304 for an unchanged product, got %d %q", rr.Code, rr.Body)
+	}
+
+	update := `{"name": "Mouse", "price": "149.90", "amount": 9}`
+	if rr := send("PUT", update, map[string]string{"If-Match": `"2"`}); rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected 412 for a stale If-Match, got %d", rr.Code)
+	}
+	rr = send("PUT", update, map[string]string{"If-Match": `"1", "3"`})
+	if rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"4"` {
+		t.Fatalf("expected 200 with ETag \"4\", got %d %q: %s", rr.Code, rr.Header().Get("ETag"), rr.Body)
+	}
+	if rr := send("GET", "", map[string]string{"If-None-Match": `"3"`}); rr.Code != http.StatusOK {
+		t.Errorf("expected 200 once the product changed, got %d", rr.Code)
+	}
+
+	if rr := send("PATCH", `{"amount": 1}`, map[string]string{"If-Match": `"3"`}); rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected 412 patching with a stale If-Match, got %d", rr.Code)
+	}
+	if rr := send("DELETE", "", map[string]string{"If-Match": `"0"`}); rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected 412 deleting with If-Match version 0, got %d", rr.Code)
+	}
+	if rr := send("DELETE", "", map[string]string{"If-Match": `W/"4"`}); rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected 412 deleting with a weak If-Match, got %d", rr.Code)
+	}
+	if rr := send("DELETE", "", map[string]string{"If-Match": `"4"`}); rr.Code != http.StatusOK {
+		t.Errorf("expected 200 deleting with the current ETag, got %d", rr.Code)
+	}
+}
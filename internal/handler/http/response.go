@@ -0,0 +1,94 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"e-commerce.com/internal/domain"
+)
+
+// ErrorResponse is the body returned for every failed request.
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// errorKinds maps each domain error kind to its HTTP status and machine-readable code.
+var errorKinds = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{domain.ErrNotFound, http.StatusNotFound, "not_found"},
+	{domain.ErrConflict, http.StatusConflict, "conflict"},
+	{domain.ErrValidation, http.StatusBadRequest, "validation_error"},
+	{domain.ErrUnavailable, http.StatusServiceUnavailable, "unavailable"},
+	{domain.ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+	{domain.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{domain.ErrPaymentDeclined, http.StatusPaymentRequired, "payment_declined"},
+	{domain.ErrPreconditionFailed, http.StatusPreconditionFailed, "precondition_failed"},
+}
+
+// codeForStatus returns the error code used when a handler fails without a domain error.
+func codeForStatus(status int) string {
+	for _, k := range errorKinds {
+		if k.status == status {
+			return k.code
+		}
+	}
+	if status >= http.StatusInternalServerError {
+		return "internal_error"
+	}
+	return "bad_request"
+}
+
+func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error marshalling JSON: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(response)
+}
+
+func respondWithError(w http.ResponseWriter, code int, message string) {
+	respondWithJSON(w, code, ErrorResponse{Error: message, Code: codeForStatus(code)})
+}
+
+// respondWithDomainError translates err into a status code and error body.
+// Unclassified errors are logged and answered with a 500 carrying fallback,
+// so internal details never leak to clients.
+func respondWithDomainError(w http.ResponseWriter, err error, fallback string) {
+	status, body := domainErrorResponse(err, fallback)
+	respondWithJSON(w, status, body)
+}
+
+// domainErrorResponse is the status and body respondWithDomainError sends for err.
+func domainErrorResponse(err error, fallback string) (int, ErrorResponse) {
+	for _, k := range errorKinds {
+		if errors.Is(err, k.kind) {
+			if k.status >= http.StatusInternalServerError {
+				log.Printf("%s: %v", fallback, err)
+			}
+			return k.status, ErrorResponse{Error: domain.ErrorMessage(err, fallback), Code: k.code}
+		}
+	}
+	log.Printf("%s: %v", fallback, err)
+	return http.StatusInternalServerError, ErrorResponse{Error: fallback, Code: "internal_error"}
+}
+
+// respondWithDecodeError answers a request whose JSON body could not be
+// decoded. Validation errors raised while decoding (e.g. a malformed price)
+// keep their message; anything else is reported as an invalid payload.
+func respondWithDecodeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrValidation) {
+		respondWithDomainError(w, err, "Invalid request payload")
+		return
+	}
+	respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"e-commerce.com/internal/domain"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ListTrash godoc
+// @Summary      List deleted products
+// @Description  Returns a paginated list of the products in the trash, each with its deleted_at time. Deleted products are purged permanently once the retention period has passed.
+// @Tags         products
+// @Produce      json
+// @Param        page   query     int  false  "Page number" default(1)
+// @Param        limit  query     int  false  "Items per page" default(50)
+// @Success      200    {object}  PaginatedResponse
+// @Failure      401    {object}  ErrorResponse
+// @Failure      403    {object}  ErrorResponse
+// @Failure      500    {object}  ErrorResponse
+// @Router       /products/trash [get]
+func (h *ProductHandler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	page, limit := pageParams(r)
+
+	products, total, err := h.repo.FindAll(r.Context(), domain.ProductFilter{Deleted: true}, page, limit)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve deleted products")
+		return
+	}
+	if products == nil {
+		products = []domain.Product{}
+	}
+
+	respondWithJSON(w, http.StatusOK, PaginatedResponse{
+		Data:        products,
+		TotalPages:  int(math.Ceil(float64(total) / float64(limit))),
+		CurrentPage: page,
+	})
+}
+
+// RestoreProduct godoc
+// @Summary      Restore a deleted product
+// @Description  Takes a product out of the trash and returns it with its new ETag. Restoring a product that is not deleted, or whose SKU or slug a live product has taken since, is a 409.
+// @Tags         products
+// @Produce      json
+// @Param        id   path      int  true  "Product ID"
+// @Success      200  {object}  domain.Product
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /products/{id}/restore [post]
+func (h *ProductHandler) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	product, err := h.repo.Restore(r.Context(), id)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to restore product")
+		return
+	}
+
+	w.Header().Set("ETag", productETag(product.Version))
+	respondWithJSON(w, http.StatusOK, product)
+}
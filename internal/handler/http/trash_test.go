@@ -0,0 +1,95 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"e-commerce.com/internal/domain"
+	"e-commerce.com/internal/storage"
+)
+
+func TestProductHandler_TrashAndRestore(t *testing.T) {
+	products := &storage.MockProductRepository{Products: []domain.Product{
+		{ID: 1, Name: "Mouse", Price: domain.NewMoney(1990, "USD"), Amount: 9, SKU: "MOUSE-1", Slug: "mouse", Version: 1},
+		{ID: 2, Name: "Cable", Price: domain.NewMoney(500, "USD"), Amount: 3, Slug: "cable", Version: 1},
+	}}
+	h := NewProductHandler(products, &storage.MockCategoryRepository{}, &storage.MockVariantRepository{})
+	call := func(handler http.HandlerFunc, method, target, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if id != "" {
+			req = withURLParam(req, "id", id)
+		}
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr
+	}
+	listed := func(rr *httptest.ResponseRecorder) []domain.Product {
+		var resp struct {
+			Data []domain.Product `json:"data"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		return resp.Data
+	}
+
+	if rr := call(h.DeleteProduct, "DELETE", "/products/1", "1"); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 on delete, got %d", rr.Code)
+	}
+	if rr := call(h.GetProduct, "GET", "/products/1", "1"); rr.Code != http.StatusNotFound {
+		t.Errorf("expected a deleted product to be gone, got %d", rr.Code)
+	}
+	bySKU := httptest.NewRecorder()
+	h.GetProductBySKU(bySKU, withURLParam(httptest.NewRequest("GET", "/products/by-sku/MOUSE-1", nil), "sku", "MOUSE-1"))
+	if bySKU.Code != http.StatusNotFound {
+		t.Errorf("expected a deleted product to be gone by SKU, got %d", bySKU.Code)
+	}
+	if got := listed(call(h.ListProducts, "GET", "/products", "")); len(got) != 1 || got[0].ID != 2 {
+		t.Errorf("expected only the cable to be listed, got %+v", got)
+	}
+	if rr := call(h.DeleteProduct, "DELETE", "/products/1", "1"); rr.Code != http.StatusNotFound {
+		t.Errorf("expected deleting twice to be a 404, got %d", rr.Code)
+	}
+
+	trash := listed(call(h.ListTrash, "GET", "/products/trash", ""))
+	if len(trash) != 1 || trash[0].ID != 1 || trash[0].DeletedAt == nil {
+		t.Fatalf("expected the mouse in the trash with its deletion time, got %+v", trash)
+	}
+
+	rr := call(h.RestoreProduct, "POST", "/products/1/restore", "1")
+	if rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"3"` {
+		t.Fatalf("expected 200 with a new ETag on restore, got %d %v", rr.Code, rr.Header())
+	}
+	if rr := call(h.GetProduct, "GET", "/products/1", "1"); rr.Code != http.StatusOK {
+		t.Errorf("expected the restored product to be back, got %d", rr.Code)
+	}
+	if rr := call(h.RestoreProduct, "POST", "/products/1/restore", "1"); rr.Code != http.StatusConflict {
+		t.Errorf("expected restoring a live product to be a 409, got %d", rr.Code)
+	}
+	if rr := call(h.RestoreProduct, "POST", "/products/9/restore", "9"); rr.Code != http.StatusNotFound {
+		t.Errorf("expected restoring a missing product to be a 404, got %d", rr.Code)
+	}
+
+	// Only products deleted before the cutoff are purged.
+	call(h.DeleteProduct, "DELETE", "/products/2", "2")
+	if n, _ := products.PurgeDeleted(context.Background(), time.Now().Add(-time.Hour)); n != 0 {
+		t.Errorf("expected a recent deletion to be kept, purged %d", n)
+	}
+	if n, _ := products.PurgeDeleted(context.Background(), time.Now().Add(time.Second)); n != 1 || len(products.Products) != 1 {
+		t.Errorf("expected the cable to be purged, purged %d leaving %+v", n, products.Products)
+	}
+
+	// A deleted product's SKU is free for a new product, which then blocks the restore.
+	call(h.DeleteProduct, "DELETE", "/products/1", "1")
+	replacement := domain.Product{Name: "Mouse", Price: domain.NewMoney(2490, "USD"), SKU: "MOUSE-1", Slug: "mouse-v2"}
+	if err := products.Save(context.Background(), &replacement); err != nil {
+		t.Fatalf("expected the SKU of a deleted product to be reusable, got %v", err)
+	}
+	if rr := call(h.RestoreProduct, "POST", "/products/1/restore", "1"); rr.Code != http.StatusConflict {
+		t.Errorf("expected restoring over a taken SKU to be a 409, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,335 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"e-commerce.com/internal/auth"
+	"e-commerce.com/internal/domain"
+)
+
+// AuthHandler serves the /auth routes and /me.
+type AuthHandler struct {
+	users    domain.UserRepository
+	tokens   domain.RefreshTokenRepository
+	issuer   *auth.Issuer
+	verifier *auth.Verifier
+
+	passwordCost int
+	dummyOnce    sync.Once
+	dummyHash    string
+}
+
+// NewAuthHandler creates a new instance of AuthHandler. verifier must accept
+// the tokens signed by issuer.
+func NewAuthHandler(users domain.UserRepository, tokens domain.RefreshTokenRepository, issuer *auth.Issuer, verifier *auth.Verifier) *AuthHandler {
+	return &AuthHandler{users: users, tokens: tokens, issuer: issuer, verifier: verifier, passwordCost: auth.DefaultPasswordCost}
+}
+
+// RegisterRequest is the body of POST /auth/register.
+type RegisterRequest struct {
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// LoginRequest is the body of POST /auth/login.
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// RefreshRequest is the body of POST /auth/refresh and POST /auth/logout.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenResponse is returned whenever a token pair is issued.
+type TokenResponse struct {
+	AccessToken  string      `json:"access_token"`
+	RefreshToken string      `json:"refresh_token"`
+	TokenType    string      `json:"token_type"`
+	ExpiresIn    int         `json:"expires_in"`
+	User         domain.User `json:"user"`
+}
+
+// Register godoc
+// @Summary      Register a new user
+// @Description  Creates a customer account and returns an access and refresh token pair for it.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        user  body      RegisterRequest  true  "Registration Payload"
+// @Success      201   {object}  TokenResponse
+// @Failure      400   {object}  ErrorResponse
+// @Failure      409   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Failure      503   {object}  ErrorResponse
+// @Router       /auth/register [post]
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req RegisterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithDecodeError(w, err)
+		return
+	}
+
+	user := domain.User{
+		Email: domain.NormalizeEmail(req.Email),
+		Name:  req.Name,
+		Roles: []string{auth.RoleCustomer},
+	}
+	if err := user.Validate(); err != nil {
+		respondWithDomainError(w, err, "Invalid user data")
+		return
+	}
+	hash, err := auth.HashPassword(req.Password, h.passwordCost)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to register user")
+		return
+	}
+	user.PasswordHash = hash
+
+	if err := h.users.Save(r.Context(), &user); err != nil {
+		respondWithDomainError(w, err, "Failed to register user")
+		return
+	}
+
+	h.respondWithTokens(r.Context(), w, http.StatusCreated, user, "")
+}
+
+// Login godoc
+// @Summary      Log in
+// @Description  Exchanges an email and password for an access and refresh token pair.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        credentials  body      LoginRequest  true  "Login Payload"
+// @Success      200          {object}  TokenResponse
+// @Failure      400          {object}  ErrorResponse
+// @Failure      401          {object}  ErrorResponse
+// @Failure      500          {object}  ErrorResponse
+// @Router       /auth/login [post]
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithDecodeError(w, err)
+		return
+	}
+
+	user, err := h.users.FindByEmail(r.Context(), req.Email)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		respondWithDomainError(w, err, "Failed to log in")
+		return
+	}
+	if err != nil {
+		// Hash anyway so response times do not reveal which emails exist.
+		auth.CheckPassword(h.dummyPasswordHash(), req.Password)
+		respondWithDomainError(w, domain.NewUnauthorizedError("invalid email or password"), "Unauthorized")
+		return
+	}
+	if !auth.CheckPassword(user.PasswordHash, req.Password) {
+		respondWithDomainError(w, domain.NewUnauthorizedError("invalid email or password"), "Unauthorized")
+		return
+	}
+
+	h.respondWithTokens(r.Context(), w, http.StatusOK, user, "")
+}
+
+// Refresh godoc
+// @Summary      Refresh an access token
+// @Description  Exchanges a refresh token for a new token pair. Every refresh token can be used once; presenting a used token again revokes every token descending from the same login.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        token  body      RefreshRequest  true  "Refresh Token"
+// @Success      200    {object}  TokenResponse
+// @Failure      400    {object}  ErrorResponse
+// @Failure      401    {object}  ErrorResponse
+// @Failure      500    {object}  ErrorResponse
+// @Router       /auth/refresh [post]
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	stored, ok := h.storedRefreshToken(w, r)
+	if !ok {
+		return
+	}
+
+	if stored.RevokedAt != nil {
+		h.revokeFamily(r.Context(), w, stored.FamilyID, domain.NewUnauthorizedError("refresh token has already been used"))
+		return
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		respondWithDomainError(w, domain.NewUnauthorizedError("refresh token has expired"), "Unauthorized")
+		return
+	}
+	if err := h.tokens.Revoke(r.Context(), stored.ID); err != nil {
+		if errors.Is(err, domain.ErrConflict) {
+			// Another request rotated this token first.
+			h.revokeFamily(r.Context(), w, stored.FamilyID, domain.NewUnauthorizedError("refresh token has already been used"))
+			return
+		}
+		respondWithDomainError(w, err, "Failed to refresh token")
+		return
+	}
+
+	user, err := h.users.FindByID(r.Context(), stored.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			err = domain.NewUnauthorizedError("user no longer exists")
+		}
+		respondWithDomainError(w, err, "Failed to refresh token")
+		return
+	}
+
+	h.respondWithTokens(r.Context(), w, http.StatusOK, user, stored.FamilyID)
+}
+
+// Logout godoc
+// @Summary      Log out
+// @Description  Revokes the refresh token and every token rotated from the same login. Access tokens already issued stay valid until they expire.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        token  body      RefreshRequest  true  "Refresh Token"
+// @Success      200    {object}  map[string]string
+// @Failure      400    {object}  ErrorResponse
+// @Failure      401    {object}  ErrorResponse
+// @Failure      500    {object}  ErrorResponse
+// @Router       /auth/logout [post]
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	stored, ok := h.storedRefreshToken(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.tokens.RevokeFamily(r.Context(), stored.FamilyID); err != nil {
+		respondWithDomainError(w, err, "Failed to log out")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Logged out successfully"})
+}
+
+// Me godoc
+// @Summary      Get the current user
+// @Description  Returns the account the bearer token was issued to.
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  domain.User
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /me [get]
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	id, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.users.FindByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			err = domain.NewUnauthorizedError("token does not belong to a registered user")
+		}
+		respondWithDomainError(w, err, "Failed to retrieve user")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, user)
+}
+
+// storedRefreshToken decodes a RefreshRequest, verifies the token and loads
+// its stored record. It writes the error response itself when it fails.
+func (h *AuthHandler) storedRefreshToken(w http.ResponseWriter, r *http.Request) (domain.RefreshToken, bool) {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithDecodeError(w, err)
+		return domain.RefreshToken{}, false
+	}
+
+	claims, err := h.verifier.VerifyRefresh(req.RefreshToken)
+	if err != nil {
+		respondWithDomainError(w, err, "Unauthorized")
+		return domain.RefreshToken{}, false
+	}
+	stored, err := h.tokens.FindByID(r.Context(), claims.ID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			err = domain.NewUnauthorizedError("invalid refresh token")
+		}
+		respondWithDomainError(w, err, "Unauthorized")
+		return domain.RefreshToken{}, false
+	}
+	return stored, true
+}
+
+// revokeFamily revokes a token family after reuse was detected and reports cause.
+func (h *AuthHandler) revokeFamily(ctx context.Context, w http.ResponseWriter, familyID string, cause error) {
+	if err := h.tokens.RevokeFamily(ctx, familyID); err != nil {
+		respondWithDomainError(w, err, "Failed to refresh token")
+		return
+	}
+	respondWithDomainError(w, cause, "Unauthorized")
+}
+
+// respondWithTokens issues a token pair for user. An empty familyID starts a
+// new family, as on login.
+func (h *AuthHandler) respondWithTokens(ctx context.Context, w http.ResponseWriter, status int, user domain.User, familyID string) {
+	if !h.issuer.Enabled() {
+		respondWithDomainError(w, domain.NewUnavailableError("authentication is not configured", nil), "Service unavailable")
+		return
+	}
+
+	var err error
+	if familyID == "" {
+		if familyID, err = auth.NewTokenID(); err != nil {
+			respondWithDomainError(w, err, "Failed to issue tokens")
+			return
+		}
+	}
+	tokenID, err := auth.NewTokenID()
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to issue tokens")
+		return
+	}
+	stored := domain.RefreshToken{
+		ID:        tokenID,
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(h.issuer.RefreshTokenTTL()),
+	}
+	if err := h.tokens.Save(ctx, &stored); err != nil {
+		respondWithDomainError(w, err, "Failed to issue tokens")
+		return
+	}
+
+	access, err := h.issuer.IssueAccessToken(user)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to issue tokens")
+		return
+	}
+	refresh, err := h.issuer.IssueRefreshToken(user.ID, stored.ID, stored.ExpiresAt)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to issue tokens")
+		return
+	}
+
+	respondWithJSON(w, status, TokenResponse{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(h.issuer.AccessTokenTTL().Seconds()),
+		User:         user,
+	})
+}
+
+// dummyPasswordHash returns a hash with the handler's cost to compare against
+// when the email is unknown.
+func (h *AuthHandler) dummyPasswordHash() string {
+	h.dummyOnce.Do(func() {
+		h.dummyHash, _ = auth.HashPassword("not-a-real-password", h.passwordCost)
+	})
+	return h.dummyHash
+}
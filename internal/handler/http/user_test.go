@@ -0,0 +1,134 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"e-commerce.com/internal/auth"
+	"e-commerce.com/internal/storage"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestAuthHandler() (*AuthHandler, *auth.Verifier) {
+	verifier := auth.NewVerifier(auth.Config{HMACSecret: []byte("secret")})
+	issuer := auth.NewIssuer(auth.IssuerConfig{HMACSecret: []byte("secret")})
+	h := NewAuthHandler(&storage.MockUserRepository{}, &storage.MockRefreshTokenRepository{}, issuer, verifier)
+	h.passwordCost = bcrypt.MinCost
+	return h, verifier
+}
+
+func postJSON(t *testing.T, handler http.HandlerFunc, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload)))
+	return rr
+}
+
+func decodeTokens(t *testing.T, rr *httptest.ResponseRecorder) TokenResponse {
+	t.Helper()
+	var tokens TokenResponse
+	if err := json.NewDecoder(rr.Body).Decode(&tokens); err != nil {
+		t.Fatal(err)
+	}
+	return tokens
+}
+
+func TestAuthHandler_RegisterLoginAndMe(t *testing.T) {
+	h, verifier := newTestAuthHandler()
+
+	rr := postJSON(t, h.Register, RegisterRequest{Email: " Ana@Example.com ", Name: "Ana", Password: "correct horse"})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("register: expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	registered := decodeTokens(t, rr)
+	if registered.User.Email != "ana@example.com" || len(registered.User.Roles) != 1 || registered.User.Roles[0] != auth.RoleCustomer {
+		t.Errorf("unexpected registered user %+v", registered.User)
+	}
+
+	if rr := postJSON(t, h.Register, RegisterRequest{Email: "ana@example.com", Name: "Ana", Password: "correct horse"}); rr.Code != http.StatusConflict {
+		t.Errorf("duplicate register: expected 409, got %d", rr.Code)
+	}
+	if rr := postJSON(t, h.Register, RegisterRequest{Email: "bob@example.com", Name: "Bob", Password: "short"}); rr.Code != http.StatusBadRequest {
+		t.Errorf("short password: expected 400, got %d", rr.Code)
+	}
+
+	if rr := postJSON(t, h.Login, LoginRequest{Email: "ana@example.com", Password: "wrong password"}); rr.Code != http.StatusUnauthorized {
+		t.Errorf("wrong password: expected 401, got %d", rr.Code)
+	}
+	if rr := postJSON(t, h.Login, LoginRequest{Email: "nobody@example.com", Password: "correct horse"}); rr.Code != http.StatusUnauthorized {
+		t.Errorf("unknown email: expected 401, got %d", rr.Code)
+	}
+	rr = postJSON(t, h.Login, LoginRequest{Email: "ANA@example.com", Password: "correct horse"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("login: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	tokens := decodeTokens(t, rr)
+
+	me := Authenticate(verifier)(http.HandlerFunc(h.Me))
+	for _, tc := range []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{"access token", tokens.AccessToken, http.StatusOK},
+		{"refresh token", tokens.RefreshToken, http.StatusUnauthorized},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			rr := httptest.NewRecorder()
+			me.ServeHTTP(rr, req)
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tc.wantStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthHandler_RefreshRotationAndReuse(t *testing.T) {
+	h, _ := newTestAuthHandler()
+
+	rr := postJSON(t, h.Register, RegisterRequest{Email: "ana@example.com", Name: "Ana", Password: "correct horse"})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("register: expected 201, got %d", rr.Code)
+	}
+	first := decodeTokens(t, rr)
+
+	rr = postJSON(t, h.Refresh, RefreshRequest{RefreshToken: first.RefreshToken})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("refresh: expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	second := decodeTokens(t, rr)
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("expected the refresh token to be rotated")
+	}
+
+	// Replaying the rotated token revokes the whole family, including the
+	// token issued in exchange for it.
+	if rr := postJSON(t, h.Refresh, RefreshRequest{RefreshToken: first.RefreshToken}); rr.Code != http.StatusUnauthorized {
+		t.Errorf("reuse: expected 401, got %d", rr.Code)
+	}
+	if rr := postJSON(t, h.Refresh, RefreshRequest{RefreshToken: second.RefreshToken}); rr.Code != http.StatusUnauthorized {
+		t.Errorf("after reuse: expected 401, got %d", rr.Code)
+	}
+	if rr := postJSON(t, h.Refresh, RefreshRequest{RefreshToken: first.AccessToken}); rr.Code != http.StatusUnauthorized {
+		t.Errorf("access token as refresh token: expected 401, got %d", rr.Code)
+	}
+
+	rr = postJSON(t, h.Login, LoginRequest{Email: "ana@example.com", Password: "correct horse"})
+	third := decodeTokens(t, rr)
+	if rr := postJSON(t, h.Logout, RefreshRequest{RefreshToken: third.RefreshToken}); rr.Code != http.StatusOK {
+		t.Errorf("logout: expected 200, got %d", rr.Code)
+	}
+	if rr := postJSON(t, h.Refresh, RefreshRequest{RefreshToken: third.RefreshToken}); rr.Code != http.StatusUnauthorized {
+		t.Errorf("after logout: expected 401, got %d", rr.Code)
+	}
+}
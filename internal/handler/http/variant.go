@@ -0,0 +1,224 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"e-commerce.com/internal/domain"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// VariantHandler serves the /products/{id}/variants routes.
+type VariantHandler struct {
+	variants domain.VariantRepository
+	products domain.ProductRepository
+}
+
+// NewVariantHandler creates a new instance of VariantHandler. The product
+// repository checks that the parent product exists and supplies its currency.
+func NewVariantHandler(variants domain.VariantRepository, products domain.ProductRepository) *VariantHandler {
+	return &VariantHandler{variants: variants, products: products}
+}
+
+// ListVariants godoc
+// @Summary      List the variants of a product
+// @Description  Returns every variant of the product, oldest first.
+// @Tags         variants
+// @Produce      json
+// @Param        id   path      int  true  "Product ID"
+// @Success      200  {array}   domain.Variant
+// @Failure      400  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /products/{id}/variants [get]
+func (h *VariantHandler) ListVariants(w http.ResponseWriter, r *http.Request) {
+	product, ok := h.loadProduct(w, r)
+	if !ok {
+		return
+	}
+
+	variants, err := h.variants.FindByProduct(r.Context(), product.ID)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve variants")
+		return
+	}
+	if variants == nil {
+		variants = []domain.Variant{}
+	}
+
+	respondWithJSON(w, http.StatusOK, variants)
+}
+
+// CreateVariant godoc
+// @Summary      Create a variant of a product
+// @Description  Adds a variant with its own SKU, option values (e.g. size and color), stock and optional price override in the product's currency.
+// @Tags         variants
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int             true  "Product ID"
+// @Param        variant  body      domain.Variant  true  "Variant Payload"
+// @Success      201      {object}  domain.Variant
+// @Failure      400      {object}  ErrorResponse
+// @Failure      404      {object}  ErrorResponse
+// @Failure      409      {object}  ErrorResponse
+// @Failure      500      {object}  ErrorResponse
+// @Router       /products/{id}/variants [post]
+func (h *VariantHandler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	product, ok := h.loadProduct(w, r)
+	if !ok {
+		return
+	}
+
+	var v domain.Variant
+	if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+		respondWithDecodeError(w, err)
+		return
+	}
+	v.ID = 0
+	v.ProductID = product.ID
+
+	if err := v.Validate(product); err != nil {
+		respondWithDomainError(w, err, "Invalid variant data")
+		return
+	}
+
+	if err := h.variants.Save(r.Context(), &v); err != nil {
+		respondWithDomainError(w, err, "Failed to create variant")
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, v)
+}
+
+// GetVariant godoc
+// @Summary      Get a variant of a product
+// @Description  Retrieves a single variant of the product.
+// @Tags         variants
+// @Produce      json
+// @Param        id         path      int  true  "Product ID"
+// @Param        variantID  path      int  true  "Variant ID"
+// @Success      200        {object}  domain.Variant
+// @Failure      400        {object}  ErrorResponse
+// @Failure      404        {object}  ErrorResponse
+// @Failure      500        {object}  ErrorResponse
+// @Router       /products/{id}/variants/{variantID} [get]
+func (h *VariantHandler) GetVariant(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, ok := variantParams(w, r)
+	if !ok {
+		return
+	}
+
+	variant, err := h.variants.FindByID(r.Context(), productID, variantID)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve variant")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, variant)
+}
+
+// UpdateVariant godoc
+// @Summary      Update a variant of a product
+// @Description  Replaces the SKU, option values, stock and price override of a variant.
+// @Tags         variants
+// @Accept       json
+// @Produce      json
+// @Param        id         path      int             true  "Product ID"
+// @Param        variantID  path      int             true  "Variant ID"
+// @Param        variant    body      domain.Variant  true  "Variant Payload"
+// @Success      200        {object}  domain.Variant
+// @Failure      400        {object}  ErrorResponse
+// @Failure      404        {object}  ErrorResponse
+// @Failure      409        {object}  ErrorResponse
+// @Failure      500        {object}  ErrorResponse
+// @Router       /products/{id}/variants/{variantID} [put]
+func (h *VariantHandler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, ok := variantParams(w, r)
+	if !ok {
+		return
+	}
+
+	product, err := h.products.FindByID(r.Context(), productID)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve product")
+		return
+	}
+
+	var v domain.Variant
+	if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+		respondWithDecodeError(w, err)
+		return
+	}
+	v.ID = variantID
+	v.ProductID = product.ID
+
+	if err := v.Validate(product); err != nil {
+		respondWithDomainError(w, err, "Invalid variant data")
+		return
+	}
+
+	if err := h.variants.Update(r.Context(), &v); err != nil {
+		respondWithDomainError(w, err, "Failed to update variant")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, v)
+}
+
+// DeleteVariant godoc
+// @Summary      Delete a variant of a product
+// @Description  Deletes a variant; the product and its other variants are kept.
+// @Tags         variants
+// @Produce      json
+// @Param        id         path      int  true  "Product ID"
+// @Param        variantID  path      int  true  "Variant ID"
+// @Success      200        {object}  map[string]string
+// @Failure      400        {object}  ErrorResponse
+// @Failure      404        {object}  ErrorResponse
+// @Failure      500        {object}  ErrorResponse
+// @Router       /products/{id}/variants/{variantID} [delete]
+func (h *VariantHandler) DeleteVariant(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, ok := variantParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.variants.Delete(r.Context(), productID, variantID); err != nil {
+		respondWithDomainError(w, err, "Failed to delete variant")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Variant deleted successfully"})
+}
+
+// loadProduct loads the product named by the id URL parameter, writing the
+// error response itself when that fails.
+func (h *VariantHandler) loadProduct(w http.ResponseWriter, r *http.Request) (domain.Product, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return domain.Product{}, false
+	}
+	product, err := h.products.FindByID(r.Context(), id)
+	if err != nil {
+		respondWithDomainError(w, err, "Failed to retrieve product")
+		return domain.Product{}, false
+	}
+	return product, true
+}
+
+func variantParams(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	productID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return 0, 0, false
+	}
+	variantID, err := strconv.Atoi(chi.URLParam(r, "variantID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid variant ID")
+		return 0, 0, false
+	}
+	return productID, variantID, true
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"e-commerce.com/internal/domain"
+	"e-commerce.com/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestVariantHandler_CRUDAndSummaries(t *testing.T) {
+	products := &storage.MockProductRepository{Products: []domain.Product{
+		{ID: 1, Name: "Shirt", Price: domain.NewMoney(4990, "BRL"), Amount: 0},
+		{ID: 2, Name: "Mug", Price: domain.NewMoney(1990, "BRL"), Amount: 4},
+	}}
+	variants := &storage.MockVariantRepository{Products: products}
+	variantH := NewVariantHandler(variants, products)
+	productH := NewProductHandler(products, &storage.MockCategoryRepository{}, variants)
+
+	router := chi.NewRouter()
+	router.Get("/products", productH.ListProducts)
+	router.Get("/products/{id}", productH.GetProduct)
+	router.Get("/products/{id}/variants", variantH.ListVariants)
+	router.Post("/products/{id}/variants", variantH.CreateVariant)
+	router.Put("/products/{id}/variants/{variantID}", variantH.UpdateVariant)
+	router.Delete("/products/{id}/variants/{variantID}", variantH.DeleteVariant)
+
+	small := map[string]interface{}{
+		"sku": "SHIRT-S", "options": map[string]string{"size": "S"}, "amount": 2,
+		"price": map[string]string{"amount": "39.90", "currency": "BRL"},
+	}
+	if rr := cartRequest(t, router, "POST", "/products/1/variants", "", small); rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201 creating a variant, got %d: %s", rr.Code, rr.Body)
+	}
+	large := map[string]interface{}{"sku": "SHIRT-L", "options": map[string]string{"size": "L"}, "amount": 5}
+	if rr := cartRequest(t, router, "POST", "/products/1/variants", "", large); rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201 creating a variant, got %d: %s", rr.Code, rr.Body)
+	}
+	if rr := cartRequest(t, router, "POST", "/products/2/variants", "", large); rr.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a duplicate SKU, got %d", rr.Code)
+	}
+	if rr := cartRequest(t, router, "POST", "/products/9/variants", "", large); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown product, got %d", rr.Code)
+	}
+	usd := map[string]interface{}{"sku": "SHIRT-XL", "amount": 1, "price": map[string]string{"amount": "10.00", "currency": "USD"}}
+	if rr := cartRequest(t, router, "POST", "/products/1/variants", "", usd); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a price in another currency, got %d", rr.Code)
+	}
+
+	rr := cartRequest(t, router, "GET", "/products", "", nil)
+	var list PaginatedResponse
+	if err := json.NewDecoder(rr.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	summary := list.Data[0].VariantSummary
+	if summary == nil || summary.Count != 2 || summary.TotalStock != 7 {
+		t.Fatalf("expected 2 variants with 7 in stock, got %+v", summary)
+	}
+	if summary.PriceRange.Min != domain.NewMoney(3990, "BRL") || summary.PriceRange.Max != domain.NewMoney(4990, "BRL") {
+		t.Errorf("expected price range 39.90-49.90, got %s-%s", summary.PriceRange.Min, summary.PriceRange.Max)
+	}
+	if list.Data[1].VariantSummary != nil {
+		t.Errorf("expected no summary for a product without variants, got %+v", list.Data[1].VariantSummary)
+	}
+
+	large["amount"] = 0
+	if rr := cartRequest(t, router, "PUT", "/products/1/variants/2", "", large); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 updating a variant, got %d: %s", rr.Code, rr.Body)
+	}
+	if rr := cartRequest(t, router, "DELETE", "/products/1/variants/1", "", nil); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 deleting a variant, got %d", rr.Code)
+	}
+	if rr := cartRequest(t, router, "DELETE", "/products/2/variants/2", "", nil); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 deleting another product's variant, got %d", rr.Code)
+	}
+
+	rr = cartRequest(t, router, "GET", "/products/1", "", nil)
+	var product domain.Product
+	if err := json.NewDecoder(rr.Body).Decode(&product); err != nil {
+		t.Fatal(err)
+	}
+	if product.VariantSummary == nil || product.VariantSummary.Count != 1 || product.VariantSummary.TotalStock != 0 {
+		t.Errorf("expected 1 variant with no stock, got %+v", product.VariantSummary)
+	}
+}
@@ -0,0 +1,54 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_index.up.sql":      {Data: []byte("CREATE INDEX i ON t (c);")},
+		"0001_create_table.up.sql":   {Data: []byte("CREATE TABLE t (c INT);")},
+		"0001_create_table.down.sql": {Data: []byte("DROP TABLE t;")},
+		"README.md":                  {Data: []byte("ignored")},
+	}
+
+	migrations, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("expected 2 migrations, got %d", len(migrations))
+	}
+	if migrations[0].Version != 1 || migrations[1].Version != 2 {
+		t.Errorf("migrations are not ordered by version: %+v", migrations)
+	}
+	if migrations[0].Name != "create_table" || migrations[0].Down != "DROP TABLE t;" {
+		t.Errorf("unexpected first migration: %+v", migrations[0])
+	}
+	if migrations[1].Down != "" {
+		t.Errorf("expected no down script for migration 2, got %q", migrations[1].Down)
+	}
+	if migrations[0].Checksum == "" || migrations[0].Checksum == migrations[1].Checksum {
+		t.Errorf("unexpected checksums: %q and %q", migrations[0].Checksum, migrations[1].Checksum)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"bad name":   {"create_table.up.sql": {Data: []byte("SELECT 1;")}},
+		"missing up": {"0001_create_table.down.sql": {Data: []byte("SELECT 1;")}},
+		"name mismatch": {
+			"0001_a.up.sql":   {Data: []byte("SELECT 1;")},
+			"0001_b.down.sql": {Data: []byte("SELECT 1;")},
+		},
+	}
+
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(fsys); err == nil {
+				t.Error("expected an error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Package payment holds the payment provider integrations and the signature
+// scheme protecting provider webhooks.
+package payment
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"e-commerce.com/internal/domain"
+)
+
+// Payment sources understood by the fake provider. Any other source is
+// treated like SourceApproved.
+const (
+	SourceApproved = "tok_approved"
+	SourceDeclined = "tok_declined"
+)
+
+// FakeProvider is a deterministic in-process PaymentProvider for tests and
+// local development. It approves every operation the real state rules allow,
+// except authorizations from SourceDeclined.
+type FakeProvider struct {
+	mu       sync.Mutex
+	next     int
+	payments map[string]*fakePayment
+}
+
+type fakePayment struct {
+	amount   domain.Money
+	status   domain.PaymentStatus
+	refunded int64
+}
+
+// NewFakeProvider creates an empty FakeProvider.
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{payments: make(map[string]*fakePayment)}
+}
+
+func (f *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (f *FakeProvider) Authorize(_ context.Context, orderID int, amount domain.Money, source string) (domain.PaymentResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.next++
+	ref := fmt.Sprintf("fake_%d_%d", orderID, f.next)
+	if source == SourceDeclined {
+		f.payments[ref] = &fakePayment{amount: amount, status: domain.PaymentStatusFailed}
+		return domain.PaymentResult{ProviderRef: ref, Reason: "card declined"}, nil
+	}
+	f.payments[ref] = &fakePayment{amount: amount, status: domain.PaymentStatusAuthorized}
+	return domain.PaymentResult{ProviderRef: ref, Approved: true}, nil
+}
+
+func (f *FakeProvider) Capture(_ context.Context, ref string, amount domain.Money) (domain.PaymentResult, error) {
+	return f.update(ref, domain.PaymentStatusAuthorized, domain.PaymentStatusCaptured, func(p *fakePayment) string {
+		if amount != p.amount {
+			return "capture amount does not match the authorization"
+		}
+		return ""
+	})
+}
+
+func (f *FakeProvider) Void(_ context.Context, ref string) (domain.PaymentResult, error) {
+	return f.update(ref, domain.PaymentStatusAuthorized, domain.PaymentStatusVoided, nil)
+}
+
+func (f *FakeProvider) Refund(_ context.Context, ref string, amount domain.Money) (domain.PaymentResult, error) {
+	return f.update(ref, domain.PaymentStatusCaptured, domain.PaymentStatusRefunded, func(p *fakePayment) string {
+		if amount.Currency != p.amount.Currency || amount.Amount <= 0 || p.refunded+amount.Amount > p.amount.Amount {
+			return "refund exceeds the captured amount"
+		}
+		p.refunded += amount.Amount
+		return ""
+	})
+}
+
+// update moves the payment ref from one state to another unless check, which
+// may be nil, returns a reason to decline.
+func (f *FakeProvider) update(ref string, from, to domain.PaymentStatus, check func(p *fakePayment) string) (domain.PaymentResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, ok := f.payments[ref]
+	if !ok {
+		return domain.PaymentResult{ProviderRef: ref, Reason: "unknown payment"}, nil
+	}
+	if p.status != from {
+		return domain.PaymentResult{ProviderRef: ref, Reason: fmt.Sprintf("payment is %s", p.status)}, nil
+	}
+	if check != nil {
+		if reason := check(p); reason != "" {
+			return domain.PaymentResult{ProviderRef: ref, Reason: reason}, nil
+		}
+	}
+	p.status = to
+	return domain.PaymentResult{ProviderRef: ref, Approved: true}, nil
+}
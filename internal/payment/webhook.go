@@ -0,0 +1,82 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"e-commerce.com/internal/domain"
+)
+
+// SignatureHeader carries the webhook signature, formatted as
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">".
+const SignatureHeader = "X-Payment-Signature"
+
+// DefaultSignatureTolerance bounds how old a signed webhook may be, which
+// limits replays of captured requests.
+const DefaultSignatureTolerance = 5 * time.Minute
+
+// Webhook event types.
+const (
+	EventCaptured = "payment.captured"
+	EventVoided   = "payment.voided"
+	EventRefunded = "payment.refunded"
+	EventFailed   = "payment.failed"
+)
+
+// Event is the body of a provider webhook.
+type Event struct {
+	Type        string `json:"type"`
+	Provider    string `json:"provider"`
+	ProviderRef string `json:"provider_ref"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// Sign returns the signature header value for body sent at t.
+func Sign(secret []byte, body []byte, t time.Time) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac(secret, ts, body))
+}
+
+// VerifySignature checks a signature header against body. Signatures older
+// than tolerance, or from the future by more than tolerance, are rejected.
+// Failures are reported as domain.ErrUnauthorized.
+func VerifySignature(secret []byte, body []byte, header string, now time.Time, tolerance time.Duration) error {
+	if len(secret) == 0 {
+		return domain.NewUnauthorizedError("webhook signing is not configured")
+	}
+
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			sig = value
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || sig == "" {
+		return domain.NewUnauthorizedError("malformed webhook signature")
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return domain.NewUnauthorizedError("webhook signature has expired")
+	}
+	want, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(want, mac(secret, ts, body)) {
+		return domain.NewUnauthorizedError("invalid webhook signature")
+	}
+	return nil
+}
+
+func mac(secret []byte, ts string, body []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(ts))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
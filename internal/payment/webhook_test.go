@@ -0,0 +1,42 @@
+package payment
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"e-commerce.com/internal/domain"
+)
+
+func TestVerifySignature(t *testing.T) {
+	secret := []byte("whsec")
+	body := []byte(`{"type":"payment.captured","provider_ref":"fake_1_1"}`)
+	now := time.Unix(1_700_000_000, 0)
+	valid := Sign(secret, body, now)
+
+	tests := []struct {
+		name   string
+		secret []byte
+		body   []byte
+		header string
+		wantOK bool
+	}{
+		{"valid", secret, body, valid, true},
+		{"tampered body", secret, []byte(`{"type":"payment.refunded"}`), valid, false},
+		{"wrong secret", []byte("other"), body, valid, false},
+		{"too old", secret, body, Sign(secret, body, now.Add(-DefaultSignatureTolerance-time.Second)), false},
+		{"malformed", secret, body, "v1=abc", false},
+		{"no secret configured", nil, body, valid, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := VerifySignature(tc.secret, tc.body, tc.header, now, DefaultSignatureTolerance)
+			if tc.wantOK && err != nil {
+				t.Fatalf("expected a valid signature, got %v", err)
+			}
+			if !tc.wantOK && !errors.Is(err, domain.ErrUnauthorized) {
+				t.Fatalf("expected an unauthorized error, got %v", err)
+			}
+		})
+	}
+}
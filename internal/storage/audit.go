@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"e-commerce.com/internal/auth"
+	"e-commerce.com/internal/domain"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// pgAuditRepository implements the AuditRepository interface for PostgreSQL.
+type pgAuditRepository struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+// NewAuditRepository creates a new instance of the audit log repository.
+func NewAuditRepository(db *sql.DB, queryTimeout time.Duration) domain.AuditRepository {
+	return &pgAuditRepository{db: db, queryTimeout: queryTimeout}
+}
+
+// auditChange is the change recordAudit appends to the log.
+type auditChange struct {
+	entity   string
+	entityID interface{}
+	action   string
+	before   interface{}
+	after    interface{}
+}
+
+// recordAudit appends change to the audit log through tx, so it commits or
+// rolls back with the change itself. The actor and request ID are taken from
+// ctx; changes made without an authenticated caller, by jobs and commands,
+// are attributed to domain.AuditActorSystem.
+func recordAudit(ctx context.Context, tx *sql.Tx, change auditChange) error {
+	before, after, err := domain.AuditDiff(change.before, change.after)
+	if err != nil {
+		return err
+	}
+	actor := domain.AuditActorSystem
+	if _, ok := auth.ClaimsFromContext(ctx); ok {
+		actor = auth.ActorFromContext(ctx)
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO audit_log (entity, entity_id, action, actor, request_id, before, after)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		change.entity, fmt.Sprint(change.entityID), change.action, actor, middleware.GetReqID(ctx),
+		nullableJSON(before), nullableJSON(after))
+	return err
+}
+
+// nullableJSON stores an absent side of a change as NULL rather than an empty string.
+func nullableJSON(data []byte) interface{} {
+	if data == nil {
+		return nil
+	}
+	return string(data)
+}
+
+func (r *pgAuditRepository) List(ctx context.Context, entity, entityID string, page, limit int) ([]domain.AuditEntry, int, error) {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	const where = ` WHERE entity = $1 AND ($2 = '' OR entity_id = $2)`
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_log`+where, entity, entityID).Scan(&total); err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, entity, entity_id, action, actor, request_id, before, after, created_at
+		FROM audit_log`+where+`
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4`,
+		entity, entityID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing audit rows: %v", err)
+		}
+	}(rows)
+
+	entries := []domain.AuditEntry{}
+	for rows.Next() {
+		var (
+			e             domain.AuditEntry
+			before, after []byte
+		)
+		if err := rows.Scan(&e.ID, &e.Entity, &e.EntityID, &e.Action, &e.Actor, &e.RequestID, &before, &after, &e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		e.Before, e.After = before, after
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, translateError(err)
+	}
+	return entries, total, nil
+}
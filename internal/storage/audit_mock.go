@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+
+	"e-commerce.com/internal/domain"
+)
+
+// MockAuditRepository is an in-memory AuditRepository. Entries are kept in
+// the order they were written.
+type MockAuditRepository struct {
+	Entries []domain.AuditEntry
+	Error   error
+}
+
+func (m *MockAuditRepository) List(_ context.Context, entity, entityID string, page, limit int) ([]domain.AuditEntry, int, error) {
+	if m.Error != nil {
+		return nil, 0, m.Error
+	}
+	var matched []domain.AuditEntry
+	for i := len(m.Entries) - 1; i >= 0; i-- {
+		if e := m.Entries[i]; e.Entity == entity && (entityID == "" || e.EntityID == entityID) {
+			matched = append(matched, e)
+		}
+	}
+
+	start := (page - 1) * limit
+	if start >= len(matched) {
+		return []domain.AuditEntry{}, len(matched), nil
+	}
+	end := start + limit
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[start:end], len(matched), nil
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"e-commerce.com/internal/domain"
+)
+
+// errBulkAborted rolls back an atomic batch after an operation failed; the
+// failure itself is reported in the results.
+var errBulkAborted = errors.New("bulk operation aborted")
+
+// Bulk runs every batch in one transaction. In best-effort mode each
+// operation gets a savepoint, so a failing one is undone without losing the
+// others. The query timeout applies to each operation rather than the batch.
+func (r *pgProductRepository) Bulk(ctx context.Context, operations []domain.BulkProductOperation, atomic bool) ([]domain.BulkProductResult, error) {
+	results := make([]domain.BulkProductResult, len(operations))
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		for i, op := range operations {
+			if atomic {
+				result, err := r.applyBulk(ctx, tx, op)
+				if err != nil {
+					domain.AbortBulk(results, i, err)
+					return errBulkAborted
+				}
+				results[i] = result
+				continue
+			}
+
+			if _, err := tx.ExecContext(ctx, "SAVEPOINT bulk_operation"); err != nil {
+				return err
+			}
+			result, err := r.applyBulk(ctx, tx, op)
+			if err != nil {
+				if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT bulk_operation"); err != nil {
+					return err
+				}
+				results[i] = domain.BulkProductResult{Status: domain.BulkFailed, Err: err}
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT bulk_operation"); err != nil {
+				return err
+			}
+			results[i] = result
+		}
+		return nil
+	})
+	if errors.Is(err, errBulkAborted) {
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// applyBulk runs a single bulk operation inside tx.
+func (r *pgProductRepository) applyBulk(ctx context.Context, tx *sql.Tx, op domain.BulkProductOperation) (domain.BulkProductResult, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if op.Op == domain.BulkDelete {
+		if err := deleteProduct(ctx, tx, op.ID, op.Version); err != nil {
+			return domain.BulkProductResult{}, err
+		}
+		return domain.BulkProductResult{Status: domain.BulkDeleted}, nil
+	}
+
+	product := *op.Product
+	if product.ID == 0 && product.SKU != "" {
+		existing, err := queryProduct(ctx, tx, "sku", product.SKU)
+		switch {
+		case err == nil:
+			product.ID = existing.ID
+		case !errors.Is(err, domain.ErrNotFound):
+			return domain.BulkProductResult{}, err
+		}
+	}
+	if product.ID == 0 {
+		if err := r.save(ctx, tx, &product); err != nil {
+			return domain.BulkProductResult{}, err
+		}
+		return domain.BulkProductResult{Status: domain.BulkCreated, Product: &product}, nil
+	}
+	if err := update(ctx, tx, &product); err != nil {
+		return domain.BulkProductResult{}, err
+	}
+	return domain.BulkProductResult{Status: domain.BulkUpdated, Product: &product}, nil
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"e-commerce.com/internal/domain"
+)
+
+func (m *MockProductRepository) Bulk(ctx context.Context, operations []domain.BulkProductOperation, atomic bool) ([]domain.BulkProductResult, error) {
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	snapshot := append([]domain.Product(nil), m.Products...)
+	results := make([]domain.BulkProductResult, len(operations))
+	for i, op := range operations {
+		result, err := m.applyBulk(ctx, op)
+		if err != nil && atomic {
+			m.Products = snapshot
+			domain.AbortBulk(results, i, err)
+			return results, nil
+		}
+		if err != nil {
+			result = domain.BulkProductResult{Status: domain.BulkFailed, Err: err}
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+func (m *MockProductRepository) applyBulk(ctx context.Context, op domain.BulkProductOperation) (domain.BulkProductResult, error) {
+	if op.Op == domain.BulkDelete {
+		if err := m.Delete(ctx, op.ID, op.Version); err != nil {
+			return domain.BulkProductResult{}, err
+		}
+		return domain.BulkProductResult{Status: domain.BulkDeleted}, nil
+	}
+
+	product := *op.Product
+	if product.ID == 0 && product.SKU != "" {
+		existing, err := m.FindBySKU(ctx, product.SKU)
+		switch {
+		case err == nil:
+			product.ID = existing.ID
+		case !errors.Is(err, domain.ErrNotFound):
+			return domain.BulkProductResult{}, err
+		}
+	}
+	if product.ID == 0 {
+		if err := m.Save(ctx, &product); err != nil {
+			return domain.BulkProductResult{}, err
+		}
+		return domain.BulkProductResult{Status: domain.BulkCreated, Product: &product}, nil
+	}
+	if err := m.Update(ctx, &product); err != nil {
+		return domain.BulkProductResult{}, err
+	}
+	return domain.BulkProductResult{Status: domain.BulkUpdated, Product: &product}, nil
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"e-commerce.com/internal/domain"
+)
+
+// pgCartRepository implements the CartRepository interface for PostgreSQL.
+type pgCartRepository struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+// NewCartRepository creates a new instance of the cart repository.
+func NewCartRepository(db *sql.DB, queryTimeout time.Duration) domain.CartRepository {
+	return &pgCartRepository{db: db, queryTimeout: queryTimeout}
+}
+
+func (r *pgCartRepository) Create(ctx context.Context, cart *domain.Cart) error {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO carts (user_id) VALUES ($1) RETURNING id, created_at, updated_at`, cart.UserID).
+		Scan(&cart.ID, &cart.CreatedAt, &cart.UpdatedAt)
+	if isUniqueViolation(err) {
+		return domain.NewConflictError("user already has a cart", err)
+	}
+	return translateError(err)
+}
+
+func (r *pgCartRepository) FindByID(ctx context.Context, id string) (domain.Cart, error) {
+	return r.findOne(ctx, "id = $1", id)
+}
+
+func (r *pgCartRepository) FindByUser(ctx context.Context, userID int) (domain.Cart, error) {
+	return r.findOne(ctx, "user_id = $1", userID)
+}
+
+func (r *pgCartRepository) findOne(ctx context.Context, condition string, arg interface{}) (domain.Cart, error) {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	return queryCart(ctx, r.db, "SELECT id, user_id, created_at, updated_at FROM carts WHERE "+condition, arg)
+}
+
+// queryCart loads the cart selected by query, which must select a single
+// cart's id, user_id, created_at and updated_at, along with its items.
+func queryCart(ctx context.Context, q queryer, query string, arg interface{}) (domain.Cart, error) {
+	var c domain.Cart
+	err := q.QueryRowContext(ctx, query, arg).Scan(&c.ID, &c.UserID, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || isInvalidText(err) {
+			return domain.Cart{}, domain.NewNotFoundError("cart not found")
+		}
+		return domain.Cart{}, translateError(err)
+	}
+
+	rows, err := q.QueryContext(ctx,
+		`SELECT product_id, name, currency, unit_price, quantity FROM cart_items WHERE cart_id = $1 ORDER BY position`, c.ID)
+	if err != nil {
+		return domain.Cart{}, translateError(err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing cart item rows: %v", err)
+		}
+	}(rows)
+
+	for rows.Next() {
+		var item domain.CartItem
+		if err := rows.Scan(&item.ProductID, &item.Name, &item.UnitPrice.Currency, &item.UnitPrice, &item.Quantity); err != nil {
+			return domain.Cart{}, err
+		}
+		c.Items = append(c.Items, item)
+	}
+	if err = rows.Err(); err != nil {
+		return domain.Cart{}, translateError(err)
+	}
+	return c, nil
+}
+
+// Update reads the cart FOR UPDATE and rewrites its items in the same
+// transaction.
+func (r *pgCartRepository) Update(ctx context.Context, id string, change func(cart *domain.Cart) error) (domain.Cart, error) {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var cart domain.Cart
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		var err error
+		cart, err = queryCart(ctx, tx, "SELECT id, user_id, created_at, updated_at FROM carts WHERE id = $1 FOR UPDATE", id)
+		if err != nil {
+			return err
+		}
+		if err := change(&cart); err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx, `UPDATE carts SET updated_at = now() WHERE id = $1 RETURNING updated_at`, cart.ID).
+			Scan(&cart.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM cart_items WHERE cart_id = $1`, cart.ID); err != nil {
+			return err
+		}
+		for i, item := range cart.Items {
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO cart_items (cart_id, product_id, position, name, currency, unit_price, quantity) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+				cart.ID, item.ProductID, i, item.Name, item.UnitPrice.Currency, item.UnitPrice, item.Quantity)
+			if isForeignKeyViolation(err) {
+				return domain.NewValidationError("product does not exist")
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.Cart{}, err
+	}
+	return cart, nil
+}
+
+func (r *pgCartRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM carts WHERE id = $1`, id)
+	if err != nil {
+		if isInvalidText(err) {
+			return domain.NewNotFoundError("cart not found")
+		}
+		return translateError(err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return domain.NewNotFoundError("cart not found")
+	}
+	return nil
+}
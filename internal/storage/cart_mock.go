@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"e-commerce.com/internal/domain"
+)
+
+// MockCartRepository is an in-memory CartRepository.
+type MockCartRepository struct {
+	Carts  []domain.Cart
+	Error  error
+	nextID int
+}
+
+func (m *MockCartRepository) Create(_ context.Context, cart *domain.Cart) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	if cart.UserID != nil {
+		for _, c := range m.Carts {
+			if c.UserID != nil && *c.UserID == *cart.UserID {
+				return domain.NewConflictError("user already has a cart", nil)
+			}
+		}
+	}
+	m.nextID++
+	cart.ID = fmt.Sprintf("00000000-0000-4000-8000-%012d", m.nextID)
+	cart.CreatedAt = time.Now()
+	cart.UpdatedAt = cart.CreatedAt
+	m.Carts = append(m.Carts, copyCart(*cart))
+	return nil
+}
+
+func (m *MockCartRepository) FindByID(_ context.Context, id string) (domain.Cart, error) {
+	if m.Error != nil {
+		return domain.Cart{}, m.Error
+	}
+	for _, c := range m.Carts {
+		if c.ID == id {
+			return copyCart(c), nil
+		}
+	}
+	return domain.Cart{}, domain.NewNotFoundError("cart not found")
+}
+
+func (m *MockCartRepository) FindByUser(_ context.Context, userID int) (domain.Cart, error) {
+	if m.Error != nil {
+		return domain.Cart{}, m.Error
+	}
+	for _, c := range m.Carts {
+		if c.UserID != nil && *c.UserID == userID {
+			return copyCart(c), nil
+		}
+	}
+	return domain.Cart{}, domain.NewNotFoundError("cart not found")
+}
+
+func (m *MockCartRepository) Update(_ context.Context, id string, change func(cart *domain.Cart) error) (domain.Cart, error) {
+	if m.Error != nil {
+		return domain.Cart{}, m.Error
+	}
+	for i := range m.Carts {
+		if m.Carts[i].ID != id {
+			continue
+		}
+		cart := copyCart(m.Carts[i])
+		if err := change(&cart); err != nil {
+			return domain.Cart{}, err
+		}
+		cart.UpdatedAt = time.Now()
+		m.Carts[i] = copyCart(cart)
+		return cart, nil
+	}
+	return domain.Cart{}, domain.NewNotFoundError("cart not found")
+}
+
+func (m *MockCartRepository) Delete(_ context.Context, id string) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	for i, c := range m.Carts {
+		if c.ID == id {
+			m.Carts = append(m.Carts[:i], m.Carts[i+1:]...)
+			return nil
+		}
+	}
+	return domain.NewNotFoundError("cart not found")
+}
+
+// copyCart detaches the items slice so callers cannot modify stored carts in place.
+func copyCart(c domain.Cart) domain.Cart {
+	c.Items = append([]domain.CartItem(nil), c.Items...)
+	return c
+}
@@ -0,0 +1,228 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"e-commerce.com/internal/domain"
+)
+
+// pgCategoryRepository implements the CategoryRepository interface for PostgreSQL.
+type pgCategoryRepository struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+// NewCategoryRepository creates a new instance of the category repository.
+func NewCategoryRepository(db *sql.DB, queryTimeout time.Duration) domain.CategoryRepository {
+	return &pgCategoryRepository{db: db, queryTimeout: queryTimeout}
+}
+
+func (r *pgCategoryRepository) Save(ctx context.Context, category *domain.Category) error {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx, `INSERT INTO categories (name, parent_id) VALUES ($1, $2) RETURNING id`,
+		category.Name, category.ParentID).Scan(&category.ID)
+	if isForeignKeyViolation(err) {
+		return domain.NewValidationError("parent category does not exist")
+	}
+	return translateError(err)
+}
+
+func (r *pgCategoryRepository) FindAll(ctx context.Context) ([]domain.Category, error) {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT id, name, parent_id FROM categories ORDER BY id ASC`)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing rows on FindAll categories: %v", err)
+		}
+	}(rows)
+
+	var categories []domain.Category
+	for rows.Next() {
+		var c domain.Category
+		if err := rows.Scan(&c.ID, &c.Name, &c.ParentID); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	return categories, nil
+}
+
+func (r *pgCategoryRepository) FindByID(ctx context.Context, id int) (domain.Category, error) {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var c domain.Category
+	err := r.db.QueryRowContext(ctx, `SELECT id, name, parent_id FROM categories WHERE id = $1`, id).
+		Scan(&c.ID, &c.Name, &c.ParentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Category{}, domain.NewNotFoundError("category not found")
+		}
+		return domain.Category{}, translateError(err)
+	}
+	return c, nil
+}
+
+// Update locks the category and, walking up from its new parent, every
+// ancestor it is moved below. Two moves that could form a cycle together
+// share a locked row, so the second one waits and then sees the first.
+func (r *pgCategoryRepository) Update(ctx context.Context, category *domain.Category) error {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		var id int
+		err := tx.QueryRowContext(ctx, `SELECT id FROM categories WHERE id = $1 FOR UPDATE`, category.ID).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.NewNotFoundError("category not found")
+		}
+		if err != nil {
+			return err
+		}
+		if category.ParentID != nil {
+			if err := lockAncestors(ctx, tx, *category.ParentID, category.ID); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE categories SET name = $1, parent_id = $2 WHERE id = $3`,
+			category.Name, category.ParentID, category.ID)
+		return err
+	})
+}
+
+// lockAncestors locks parent and the categories above it, failing if moved
+// is among them, i.e. if moving it below parent would form a cycle.
+func lockAncestors(ctx context.Context, tx *sql.Tx, parent, moved int) error {
+	seen := make(map[int]bool)
+	for id := parent; ; {
+		if id == moved {
+			return domain.NewValidationError("a category cannot be moved below itself")
+		}
+		if seen[id] {
+			return fmt.Errorf("category %d is part of a cycle", id)
+		}
+		seen[id] = true
+
+		var next sql.NullInt64
+		err := tx.QueryRowContext(ctx, `SELECT parent_id FROM categories WHERE id = $1 FOR UPDATE`, id).Scan(&next)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.NewValidationError("parent category does not exist")
+		}
+		if err != nil {
+			return err
+		}
+		if !next.Valid {
+			return nil
+		}
+		id = int(next.Int64)
+	}
+}
+
+func (r *pgCategoryRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM categories WHERE id = $1`, id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return domain.NewConflictError("category still has subcategories", err)
+		}
+		return translateError(err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return domain.NewNotFoundError("category not found")
+	}
+	return nil
+}
+
+func (r *pgCategoryRepository) Descendants(ctx context.Context, id int) ([]int, error) {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	// The path guard stops the recursion should the tree ever contain a cycle.
+	rows, err := r.db.QueryContext(ctx, `WITH RECURSIVE subtree (id, depth, path) AS (
+			SELECT id, 0, ARRAY[id] FROM categories WHERE id = $1
+			UNION ALL
+			SELECT c.id, s.depth + 1, s.path || c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+			WHERE c.id <> ALL(s.path)
+		)
+		SELECT id FROM subtree ORDER BY depth, id`, id)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing rows on Descendants: %v", err)
+		}
+	}(rows)
+
+	var ids []int
+	for rows.Next() {
+		var childID int
+		if err := rows.Scan(&childID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, childID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	if len(ids) == 0 {
+		return nil, domain.NewNotFoundError("category not found")
+	}
+	return ids, nil
+}
+
+func (r *pgCategoryRepository) AssignProduct(ctx context.Context, categoryID, productID int) error {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO product_categories (product_id, category_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		productID, categoryID)
+	if isForeignKeyViolation(err) {
+		return domain.NewNotFoundError("category or product not found")
+	}
+	return translateError(err)
+}
+
+func (r *pgCategoryRepository) UnassignProduct(ctx context.Context, categoryID, productID int) error {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM product_categories WHERE product_id = $1 AND category_id = $2`, productID, categoryID)
+	if err != nil {
+		return translateError(err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return domain.NewNotFoundError("product is not assigned to this category")
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+
+	"e-commerce.com/internal/domain"
+)
+
+// MockCategoryRepository is an in-memory CategoryRepository. When Products is
+// set, product assignments are recorded in Products.Categories so category
+// filters on the product mock see them.
+type MockCategoryRepository struct {
+	Categories []domain.Category
+	Products   *MockProductRepository
+	Error      error
+}
+
+func (m *MockCategoryRepository) Save(_ context.Context, category *domain.Category) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	if category.ParentID != nil && m.indexOf(*category.ParentID) == -1 {
+		return domain.NewValidationError("parent category does not exist")
+	}
+	category.ID = m.nextID()
+	m.Categories = append(m.Categories, *category)
+	return nil
+}
+
+func (m *MockCategoryRepository) FindAll(_ context.Context) ([]domain.Category, error) {
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	return m.Categories, nil
+}
+
+func (m *MockCategoryRepository) FindByID(_ context.Context, id int) (domain.Category, error) {
+	if m.Error != nil {
+		return domain.Category{}, m.Error
+	}
+	idx := m.indexOf(id)
+	if idx == -1 {
+		return domain.Category{}, domain.NewNotFoundError("category not found")
+	}
+	return m.Categories[idx], nil
+}
+
+func (m *MockCategoryRepository) Update(ctx context.Context, category *domain.Category) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	idx := m.indexOf(category.ID)
+	if idx == -1 {
+		return domain.NewNotFoundError("category not found")
+	}
+	if category.ParentID != nil {
+		if m.indexOf(*category.ParentID) == -1 {
+			return domain.NewValidationError("parent category does not exist")
+		}
+		subtree, _ := m.Descendants(ctx, category.ID)
+		for _, id := range subtree {
+			if id == *category.ParentID {
+				return domain.NewValidationError("a category cannot be moved below itself")
+			}
+		}
+	}
+	m.Categories[idx] = *category
+	return nil
+}
+
+func (m *MockCategoryRepository) Delete(_ context.Context, id int) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	idx := m.indexOf(id)
+	if idx == -1 {
+		return domain.NewNotFoundError("category not found")
+	}
+	for _, c := range m.Categories {
+		if c.ParentID != nil && *c.ParentID == id {
+			return domain.NewConflictError("category still has subcategories", nil)
+		}
+	}
+	m.Categories = append(m.Categories[:idx], m.Categories[idx+1:]...)
+	return nil
+}
+
+func (m *MockCategoryRepository) Descendants(_ context.Context, id int) ([]int, error) {
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	if m.indexOf(id) == -1 {
+		return nil, domain.NewNotFoundError("category not found")
+	}
+	ids := []int{id}
+	for i := 0; i < len(ids); i++ {
+		for _, c := range m.Categories {
+			if c.ParentID != nil && *c.ParentID == ids[i] {
+				ids = append(ids, c.ID)
+			}
+		}
+	}
+	return ids, nil
+}
+
+func (m *MockCategoryRepository) AssignProduct(ctx context.Context, categoryID, productID int) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	if m.indexOf(categoryID) == -1 {
+		return domain.NewNotFoundError("category or product not found")
+	}
+	if m.Products == nil {
+		return nil
+	}
+	if _, err := m.Products.FindByID(ctx, productID); err != nil {
+		return domain.NewNotFoundError("category or product not found")
+	}
+	if m.Products.Categories == nil {
+		m.Products.Categories = make(map[int][]int)
+	}
+	for _, id := range m.Products.Categories[productID] {
+		if id == categoryID {
+			return nil
+		}
+	}
+	m.Products.Categories[productID] = append(m.Products.Categories[productID], categoryID)
+	return nil
+}
+
+func (m *MockCategoryRepository) UnassignProduct(_ context.Context, categoryID, productID int) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	if m.Products != nil {
+		assigned := m.Products.Categories[productID]
+		for i, id := range assigned {
+			if id == categoryID {
+				m.Products.Categories[productID] = append(assigned[:i], assigned[i+1:]...)
+				return nil
+			}
+		}
+	}
+	return domain.NewNotFoundError("product is not assigned to this category")
+}
+
+func (m *MockCategoryRepository) indexOf(id int) int {
+	for i, c := range m.Categories {
+		if c.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *MockCategoryRepository) nextID() int {
+	maxID := 0
+	for _, c := range m.Categories {
+		if c.ID > maxID {
+			maxID = c.ID
+		}
+	}
+	return maxID + 1
+}
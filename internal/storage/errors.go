@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"time"
+
+	"e-commerce.com/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+// translateError maps driver errors to the domain error kinds so handlers
+// never need to know about PostgreSQL. Errors it does not recognise are
+// returned unchanged and end up as 500s.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	// Already translated, e.g. by a repository-specific translation inside inTx.
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		return err
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return domain.NewUnavailableError("database query timed out", err)
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "22": // data_exception, e.g. numeric overflow
+			return &domain.Error{Kind: domain.ErrValidation, Message: "invalid data", Err: err}
+		case "23": // integrity_constraint_violation
+			return domain.NewConflictError("resource conflicts with existing data", err)
+		case "40": // transaction_rollback, e.g. a deadlock between concurrent writes
+			return domain.NewConflictError("concurrent update, please retry", err)
+		case "08", "53", "57": // connection, insufficient resources, operator intervention
+			return domain.NewUnavailableError("database unavailable", err)
+		}
+		return err
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.As(err, &netErr) {
+		return domain.NewUnavailableError("database unavailable", err)
+	}
+	return err
+}
+
+// isForeignKeyViolation reports whether err is a PostgreSQL foreign_key_violation.
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique_violation.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// uniqueViolationOn reports whether err is a unique_violation of the named constraint.
+func uniqueViolationOn(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
+
+// isInvalidText reports whether err is a PostgreSQL invalid_text_representation,
+// e.g. a malformed UUID.
+func isInvalidText(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "22P02"
+}
+
+// withTimeout bounds ctx by a repository's query timeout; zero disables the limit.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
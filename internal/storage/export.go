@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"e-commerce.com/internal/domain"
+)
+
+// exportFetchSize is how many rows Stream fetches from its cursor at a time.
+const exportFetchSize = 500
+
+// Stream reads the products through a server-side cursor, so only one fetch
+// of rows is in memory at a time. The query timeout applies to each fetch
+// rather than to the whole export.
+func (r *pgProductRepository) Stream(ctx context.Context, filter domain.ProductFilter, fn func(domain.Product) error) error {
+	conditions, args := productConditions(filter)
+	declare := "DECLARE product_export NO SCROLL CURSOR FOR SELECT " + productColumns + " FROM products" +
+		whereClause(conditions) + productOrderClause(filter.Sort, false)
+
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, declare, args...); err != nil {
+			return err
+		}
+		for {
+			products, err := r.fetchExport(ctx, tx)
+			if err != nil {
+				return err
+			}
+			for _, p := range products {
+				if err := fn(p); err != nil {
+					return err
+				}
+			}
+			if len(products) < exportFetchSize {
+				return nil
+			}
+		}
+	})
+}
+
+func (r *pgProductRepository) fetchExport(ctx context.Context, tx *sql.Tx) ([]domain.Product, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	// FETCH takes no parameters, so the count is part of the statement.
+	return queryProducts(ctx, tx, fmt.Sprintf("FETCH FORWARD %d FROM product_export", exportFetchSize))
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"e-commerce.com/internal/domain"
+)
+
+// pgIdempotencyRepository implements the IdempotencyRepository interface for PostgreSQL.
+type pgIdempotencyRepository struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+// NewIdempotencyRepository creates a new instance of the idempotency key repository.
+func NewIdempotencyRepository(db *sql.DB, queryTimeout time.Duration) domain.IdempotencyRepository {
+	return &pgIdempotencyRepository{db: db, queryTimeout: queryTimeout}
+}
+
+// Begin relies on INSERT ... ON CONFLICT so two concurrent requests with the
+// same key cannot both claim it. The conditional DO UPDATE takes over expired
+// records in the same statement.
+func (r *pgIdempotencyRepository) Begin(ctx context.Context, record domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var claimed bool
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO idempotency_keys (scope, key, fingerprint, expires_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (scope, key) DO UPDATE
+			SET fingerprint = EXCLUDED.fingerprint, status = NULL, headers = NULL, body = NULL,
+			    created_at = now(), expires_at = EXCLUDED.expires_at
+			WHERE idempotency_keys.expires_at <= now()
+		RETURNING true`,
+		record.Scope, record.Key, record.Fingerprint, record.ExpiresAt).Scan(&claimed)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, translateError(err)
+	}
+
+	existing := domain.IdempotencyRecord{Scope: record.Scope, Key: record.Key}
+	var (
+		status  sql.NullInt64
+		headers []byte
+		body    []byte
+	)
+	err = r.db.QueryRowContext(ctx,
+		`SELECT fingerprint, status, headers, body, expires_at FROM idempotency_keys WHERE scope = $1 AND key = $2`,
+		record.Scope, record.Key).Scan(&existing.Fingerprint, &status, &headers, &body, &existing.ExpiresAt)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	if status.Valid {
+		existing.Response = &domain.IdempotentResponse{Status: int(status.Int64), Body: body}
+		if len(headers) > 0 {
+			if err := json.Unmarshal(headers, &existing.Response.Header); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &existing, nil
+}
+
+func (r *pgIdempotencyRepository) Complete(ctx context.Context, scope, key string, response domain.IdempotentResponse) error {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	headers, err := json.Marshal(response.Header)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx,
+		`UPDATE idempotency_keys SET status = $1, headers = $2, body = $3 WHERE scope = $4 AND key = $5`,
+		response.Status, headers, response.Body, scope, key)
+	return translateError(err)
+}
+
+func (r *pgIdempotencyRepository) Release(ctx context.Context, scope, key string) error {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND status IS NULL`, scope, key)
+	return translateError(err)
+}
+
+func (r *pgIdempotencyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= now()`)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return res.RowsAffected()
+}
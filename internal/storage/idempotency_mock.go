@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"e-commerce.com/internal/domain"
+)
+
+// MockIdempotencyRepository is an in-memory IdempotencyRepository. Now
+// defaults to time.Now and can be replaced to test expiry.
+type MockIdempotencyRepository struct {
+	Records map[string]domain.IdempotencyRecord
+	Now     func() time.Time
+	Error   error
+
+	mu sync.Mutex
+}
+
+func (m *MockIdempotencyRepository) Begin(_ context.Context, record domain.IdempotencyRecord) (*domain.IdempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	if m.Records == nil {
+		m.Records = make(map[string]domain.IdempotencyRecord)
+	}
+	id := record.Scope + "\x00" + record.Key
+	if existing, ok := m.Records[id]; ok && existing.ExpiresAt.After(m.now()) {
+		return &existing, nil
+	}
+	record.Response = nil
+	m.Records[id] = record
+	return nil, nil
+}
+
+func (m *MockIdempotencyRepository) Complete(_ context.Context, scope, key string, response domain.IdempotentResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Error != nil {
+		return m.Error
+	}
+	id := scope + "\x00" + key
+	if record, ok := m.Records[id]; ok {
+		record.Response = &response
+		m.Records[id] = record
+	}
+	return nil
+}
+
+func (m *MockIdempotencyRepository) Release(_ context.Context, scope, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Error != nil {
+		return m.Error
+	}
+	id := scope + "\x00" + key
+	if record, ok := m.Records[id]; ok && record.Response == nil {
+		delete(m.Records, id)
+	}
+	return nil
+}
+
+func (m *MockIdempotencyRepository) DeleteExpired(_ context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Error != nil {
+		return 0, m.Error
+	}
+	var n int64
+	for id, record := range m.Records {
+		if !record.ExpiresAt.After(m.now()) {
+			delete(m.Records, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MockIdempotencyRepository) now() time.Time {
+	if m.Now != nil {
+		return m.Now()
+	}
+	return time.Now()
+}
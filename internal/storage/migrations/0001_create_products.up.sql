@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS products (
+    id          SERIAL PRIMARY KEY,
+    name        TEXT           NOT NULL,
+    price       NUMERIC(10, 2) NOT NULL,
+    amount      INTEGER        NOT NULL,
+    description TEXT
+);
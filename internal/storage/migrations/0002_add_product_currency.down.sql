@@ -0,0 +1,2 @@
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_price_non_negative;
+ALTER TABLE products DROP COLUMN IF EXISTS currency;
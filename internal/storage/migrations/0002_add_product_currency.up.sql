@@ -0,0 +1,2 @@
+ALTER TABLE products ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'BRL';
+ALTER TABLE products ADD CONSTRAINT products_price_non_negative CHECK (price >= 0);
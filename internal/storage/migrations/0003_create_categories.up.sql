@@ -0,0 +1,15 @@
+CREATE TABLE categories (
+    id        SERIAL PRIMARY KEY,
+    name      TEXT NOT NULL,
+    parent_id INTEGER REFERENCES categories (id) ON DELETE RESTRICT
+);
+
+CREATE INDEX categories_parent_id_idx ON categories (parent_id);
+
+CREATE TABLE product_categories (
+    product_id  INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    category_id INTEGER NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    PRIMARY KEY (product_id, category_id)
+);
+
+CREATE INDEX product_categories_category_id_idx ON product_categories (category_id);
@@ -0,0 +1,4 @@
+-- Composite indexes matching the (sort column, id) keys used by keyset pagination.
+CREATE INDEX IF NOT EXISTS products_name_id_idx ON products (name, id);
+CREATE INDEX IF NOT EXISTS products_price_id_idx ON products (price, id);
+CREATE INDEX IF NOT EXISTS products_amount_id_idx ON products (amount, id);
@@ -0,0 +1,17 @@
+CREATE TABLE carts (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    INTEGER UNIQUE REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE cart_items (
+    cart_id    UUID           NOT NULL REFERENCES carts (id) ON DELETE CASCADE,
+    product_id INTEGER        NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    position   INTEGER        NOT NULL,
+    name       TEXT           NOT NULL,
+    currency   CHAR(3)        NOT NULL,
+    unit_price NUMERIC(10, 2) NOT NULL,
+    quantity   INTEGER        NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (cart_id, product_id)
+);
@@ -0,0 +1,22 @@
+CREATE TABLE orders (
+    id         SERIAL PRIMARY KEY,
+    user_id    INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    status     TEXT           NOT NULL DEFAULT 'pending',
+    currency   CHAR(3)        NOT NULL,
+    total      NUMERIC(14, 2) NOT NULL,
+    created_at TIMESTAMPTZ    NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ    NOT NULL DEFAULT now()
+);
+
+CREATE INDEX orders_user_id_idx ON orders (user_id);
+
+-- product_id deliberately has no foreign key: orders outlive the products they list.
+CREATE TABLE order_items (
+    order_id   INTEGER        NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    product_id INTEGER        NOT NULL,
+    name       TEXT           NOT NULL,
+    currency   CHAR(3)        NOT NULL,
+    unit_price NUMERIC(10, 2) NOT NULL,
+    quantity   INTEGER        NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (order_id, product_id)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS order_transitions;
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_valid;
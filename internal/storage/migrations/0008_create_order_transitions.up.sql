@@ -0,0 +1,14 @@
+ALTER TABLE orders ADD CONSTRAINT orders_status_valid
+    CHECK (status IN ('pending', 'paid', 'fulfilled', 'shipped', 'delivered', 'cancelled', 'refunded'));
+
+CREATE TABLE order_transitions (
+    id          SERIAL PRIMARY KEY,
+    order_id    INTEGER     NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    from_status TEXT        NOT NULL,
+    to_status   TEXT        NOT NULL,
+    actor       TEXT        NOT NULL,
+    note        TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX order_transitions_order_id_idx ON order_transitions (order_id);
@@ -0,0 +1,15 @@
+CREATE TABLE payments (
+    id             SERIAL PRIMARY KEY,
+    order_id       INTEGER        NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    provider       TEXT           NOT NULL,
+    provider_ref   TEXT           NOT NULL,
+    status         TEXT           NOT NULL,
+    currency       CHAR(3)        NOT NULL,
+    amount         NUMERIC(14, 2) NOT NULL,
+    failure_reason TEXT           NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ    NOT NULL DEFAULT now(),
+    updated_at     TIMESTAMPTZ    NOT NULL DEFAULT now(),
+    UNIQUE (provider, provider_ref)
+);
+
+CREATE INDEX payments_order_id_idx ON payments (order_id);
@@ -0,0 +1,11 @@
+-- price overrides the product price when set; it shares the product's currency.
+CREATE TABLE product_variants (
+    id         SERIAL PRIMARY KEY,
+    product_id INTEGER        NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    sku        TEXT           NOT NULL UNIQUE,
+    options    JSONB          NOT NULL DEFAULT '{}',
+    price      NUMERIC(10, 2) CHECK (price >= 0),
+    amount     INTEGER        NOT NULL CHECK (amount >= 0)
+);
+
+CREATE INDEX product_variants_product_id_idx ON product_variants (product_id);
@@ -0,0 +1,3 @@
+ALTER TABLE products
+    DROP COLUMN IF EXISTS slug,
+    DROP COLUMN IF EXISTS sku;
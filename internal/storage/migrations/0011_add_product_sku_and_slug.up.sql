@@ -0,0 +1,34 @@
+ALTER TABLE products
+    ADD COLUMN sku  TEXT,
+    ADD COLUMN slug TEXT,
+    ADD CONSTRAINT products_sku_key UNIQUE (sku),
+    ADD CONSTRAINT products_slug_key UNIQUE (slug);
+
+-- Backfill slugs the way domain.Slugify builds them (for the common Latin
+-- accents), truncating before the trailing hyphen is trimmed. Products are
+-- numbered in ID order like freeSlug does, so a duplicate skips base-2 when
+-- another product's name already yields it. The unique index above serves the
+-- lookups; NULL slugs do not collide.
+DO $$
+DECLARE
+    product   RECORD;
+    base      TEXT;
+    candidate TEXT;
+    n         INTEGER;
+BEGIN
+    FOR product IN SELECT id, name FROM products ORDER BY id LOOP
+        base := COALESCE(NULLIF(rtrim(left(ltrim(regexp_replace(
+            translate(lower(product.name), 'áàâãäåçéèêëíìîïñóòôõöúùûüý', 'aaaaaaceeeeiiiinooooouuuuy'),
+            '[^a-z0-9]+', '-', 'g'), '-'), 80), '-'), ''), 'product');
+        candidate := base;
+        n := 1;
+        WHILE EXISTS (SELECT 1 FROM products WHERE slug = candidate) LOOP
+            n := n + 1;
+            candidate := base || '-' || n;
+        END LOOP;
+        UPDATE products SET slug = candidate WHERE id = product.id;
+    END LOOP;
+END
+$$;
+
+ALTER TABLE products ALTER COLUMN slug SET NOT NULL;
@@ -0,0 +1,8 @@
+-- Names weigh more than descriptions when ranking. The 'simple' configuration
+-- does no stemming, so Portuguese and English names match equally well.
+ALTER TABLE products ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+) STORED;
+
+CREATE INDEX products_search_vector_idx ON products USING GIN (search_vector);
@@ -0,0 +1,4 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Serves both the prefix LIKE and the fuzzy word-similarity match of Suggest.
+CREATE INDEX products_name_trgm_idx ON products USING GIN (lower(name) gin_trgm_ops);
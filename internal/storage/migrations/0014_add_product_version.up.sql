@@ -0,0 +1,2 @@
+-- Bumped by every write to a product or its variants; see domain.Product.Version.
+ALTER TABLE products ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
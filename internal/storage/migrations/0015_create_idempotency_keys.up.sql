@@ -0,0 +1,14 @@
+-- status is NULL while the first request with the key is in progress.
+CREATE TABLE idempotency_keys (
+    scope       TEXT        NOT NULL,
+    key         TEXT        NOT NULL,
+    fingerprint TEXT        NOT NULL,
+    status      INTEGER,
+    headers     JSONB,
+    body        BYTEA,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at  TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
@@ -0,0 +1,6 @@
+-- Soft delete: deleted products stay in the table, hidden from the catalog,
+-- until they are restored or purged after the retention period.
+ALTER TABLE products ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- Serves the trash listing and the purge job; live products are not indexed.
+CREATE INDEX products_deleted_at_idx ON products (deleted_at) WHERE deleted_at IS NOT NULL;
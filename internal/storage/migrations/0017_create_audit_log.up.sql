@@ -0,0 +1,27 @@
+-- One row per change to an audited entity, written in the same transaction as
+-- the change. before and after hold the fields that changed; a create has no
+-- before and a purge no after.
+CREATE TABLE audit_log (
+    id         BIGSERIAL   PRIMARY KEY,
+    entity     TEXT        NOT NULL,
+    entity_id  TEXT        NOT NULL,
+    action     TEXT        NOT NULL,
+    actor      TEXT        NOT NULL,
+    request_id TEXT        NOT NULL DEFAULT '',
+    before     JSONB,
+    after      JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity, entity_id, id);
+
+-- The log is append-only: history cannot be rewritten, not even by the API.
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
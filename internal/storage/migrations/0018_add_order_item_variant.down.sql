@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS order_items_line_key;
+ALTER TABLE order_items DROP COLUMN IF EXISTS variant_id;
+ALTER TABLE order_items ADD PRIMARY KEY (order_id, product_id);
@@ -0,0 +1,5 @@
+-- An order can list several variants of one product, so a line is identified
+-- by its variant too. Like product_id, variant_id has no foreign key.
+ALTER TABLE order_items ADD COLUMN variant_id INTEGER;
+ALTER TABLE order_items DROP CONSTRAINT order_items_pkey;
+CREATE UNIQUE INDEX order_items_line_key ON order_items (order_id, product_id, COALESCE(variant_id, 0));
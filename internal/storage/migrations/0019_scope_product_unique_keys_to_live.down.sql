@@ -0,0 +1,7 @@
+-- Fails if a deleted product shares its SKU or slug with another product.
+DROP INDEX IF EXISTS products_slug_key;
+DROP INDEX IF EXISTS products_sku_key;
+
+ALTER TABLE products
+    ADD CONSTRAINT products_sku_key UNIQUE (sku),
+    ADD CONSTRAINT products_slug_key UNIQUE (slug);
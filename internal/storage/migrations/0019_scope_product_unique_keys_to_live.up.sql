@@ -0,0 +1,9 @@
+-- A deleted product no longer holds on to its SKU and slug, so a new product
+-- can take them. Restoring it while a live product uses either is a conflict.
+-- The indexes keep the constraint names the storage layer matches errors on.
+ALTER TABLE products
+    DROP CONSTRAINT products_sku_key,
+    DROP CONSTRAINT products_slug_key;
+
+CREATE UNIQUE INDEX products_sku_key ON products (sku) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX products_slug_key ON products (slug) WHERE deleted_at IS NULL;
@@ -0,0 +1,3 @@
+-- An order holds at most one open authorization, so capturing one never
+-- leaves another on hold at the provider.
+CREATE UNIQUE INDEX payments_order_authorized_key ON payments (order_id) WHERE status = 'authorized';
@@ -0,0 +1,11 @@
+// Package migrations holds the versioned SQL scripts that build the database
+// schema. Files are named <version>_<name>.up.sql and <version>_<name>.down.sql
+// and are applied in version order by package migrate.
+package migrations
+
+import "embed"
+
+// FS contains every migration script shipped with the binary.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,370 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"e-commerce.com/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+// pgOrderRepository implements the OrderRepository interface for PostgreSQL.
+type pgOrderRepository struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+// NewOrderRepository creates a new instance of the order repository.
+func NewOrderRepository(db *sql.DB, queryTimeout time.Duration) domain.OrderRepository {
+	return &pgOrderRepository{db: db, queryTimeout: queryTimeout}
+}
+
+// Place reserves stock with a conditional UPDATE per item: the row lock it
+// takes makes a concurrent checkout of the same product wait, and the
+// "amount >= quantity" check is re-evaluated once that checkout commits, so
+// the last unit can never be sold twice.
+func (r *pgOrderRepository) Place(ctx context.Context, order *domain.Order) error {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		for i := range order.Items {
+			if err := reserveStock(ctx, tx, &order.Items[i]); err != nil {
+				return err
+			}
+		}
+		if err := order.UpdateTotal(); err != nil {
+			return err
+		}
+
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO orders (user_id, status, currency, total) VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at`,
+			order.UserID, order.Status, order.Total.Currency, order.Total).
+			Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		for _, item := range order.Items {
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO order_items (order_id, product_id, variant_id, name, currency, unit_price, quantity) VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $7)`,
+				order.ID, item.ProductID, item.VariantID, item.Name, item.UnitPrice.Currency, item.UnitPrice, item.Quantity)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// reserveStock takes item.Quantity units of the product, or its variant, out
+// of stock and copies its name and price into item.
+func reserveStock(ctx context.Context, tx *sql.Tx, item *domain.OrderItem) error {
+	if item.VariantID != 0 {
+		return reserveVariantStock(ctx, tx, item)
+	}
+	var amount int
+	err := tx.QueryRowContext(ctx,
+		`UPDATE products SET amount = amount - $1, version = version + 1 WHERE id = $2 AND deleted_at IS NULL AND amount >= $1 RETURNING name, currency, price, amount`,
+		item.Quantity, item.ProductID).
+		Scan(&item.Name, &item.UnitPrice.Currency, &item.UnitPrice, &amount)
+	if err == nil {
+		return recordStockChange(ctx, tx, item.ProductID, domain.AuditReserve, amount+item.Quantity, amount)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM products WHERE id = $1 AND deleted_at IS NULL)`, item.ProductID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return domain.NewNotFoundError(fmt.Sprintf("product %d not found", item.ProductID))
+	}
+	return domain.NewConflictError(fmt.Sprintf("insufficient stock for product %d", item.ProductID), nil)
+}
+
+func (r *pgOrderRepository) FindByID(ctx context.Context, id int) (domain.Order, error) {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	orders, err := r.queryOrders(ctx, "id = $1", id)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	if len(orders) == 0 {
+		return domain.Order{}, domain.NewNotFoundError("order not found")
+	}
+	return orders[0], nil
+}
+
+func (r *pgOrderRepository) FindByUser(ctx context.Context, userID int) ([]domain.Order, error) {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	return r.queryOrders(ctx, "user_id = $1", userID)
+}
+
+// queryOrders loads the orders matching condition, newest first, with their items.
+func (r *pgOrderRepository) queryOrders(ctx context.Context, condition string, arg interface{}) ([]domain.Order, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT id, user_id, status, currency, total, created_at, updated_at FROM orders WHERE "+condition+" ORDER BY id DESC", arg)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing order rows: %v", err)
+		}
+	}(rows)
+
+	var orders []domain.Order
+	index := make(map[int]int)
+	for rows.Next() {
+		var o domain.Order
+		if err := rows.Scan(&o.ID, &o.UserID, &o.Status, &o.Total.Currency, &o.Total, &o.CreatedAt, &o.UpdatedAt); err != nil {
+			return nil, err
+		}
+		index[o.ID] = len(orders)
+		orders = append(orders, o)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	if len(orders) == 0 {
+		return orders, nil
+	}
+
+	ids := make([]int, 0, len(orders))
+	for _, o := range orders {
+		ids = append(ids, o.ID)
+	}
+	itemRows, err := r.db.QueryContext(ctx,
+		`SELECT order_id, product_id, COALESCE(variant_id, 0), name, currency, unit_price, quantity FROM order_items
+		 WHERE order_id = ANY($1) ORDER BY product_id, variant_id NULLS FIRST`,
+		pq.Array(ids))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing order item rows: %v", err)
+		}
+	}(itemRows)
+
+	for itemRows.Next() {
+		var (
+			orderID int
+			item    domain.OrderItem
+		)
+		if err := itemRows.Scan(&orderID, &item.ProductID, &item.VariantID, &item.Name, &item.UnitPrice.Currency, &item.UnitPrice, &item.Quantity); err != nil {
+			return nil, err
+		}
+		o := &orders[index[orderID]]
+		o.Items = append(o.Items, item)
+	}
+	if err = itemRows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	return orders, nil
+}
+
+// Transition locks the order row, so concurrent transitions of one order are
+// applied one after the other and each is checked against the state left by
+// the previous one.
+func (r *pgOrderRepository) Transition(ctx context.Context, id int, to domain.OrderStatus, actor, note string) (domain.Order, error) {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return transitionOrder(ctx, tx, id, to, actor, note)
+	})
+	if err != nil {
+		return domain.Order{}, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+// transitionOrder moves an order to a new state inside tx. It is shared with
+// the payment repository, which settles orders in the transaction that
+// updates their payment.
+func transitionOrder(ctx context.Context, tx *sql.Tx, id int, to domain.OrderStatus, actor, note string) error {
+	order := domain.Order{ID: id}
+	err := tx.QueryRowContext(ctx, `SELECT status FROM orders WHERE id = $1 FOR UPDATE`, id).Scan(&order.Status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.NewNotFoundError("order not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	t, err := order.Transition(to, actor, note, time.Now())
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE orders SET status = $1, updated_at = now() WHERE id = $2`, t.To, id); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO order_transitions (order_id, from_status, to_status, actor, note) VALUES ($1, $2, $3, $4, $5)`,
+		id, t.From, t.To, t.Actor, t.Note)
+	if err != nil {
+		return err
+	}
+
+	if domain.ReleasesStock(t.From, t.To) {
+		return releaseStock(ctx, tx, id)
+	}
+	return nil
+}
+
+// releaseStock returns an order's items to stock. Products are updated in ID
+// order, the same order checkouts lock them in, so the two cannot deadlock.
+// Trashed products get their stock back too, so it is right if they are
+// restored; only items whose product has been purged are skipped.
+func releaseStock(ctx context.Context, tx *sql.Tx, orderID int) error {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT product_id, COALESCE(variant_id, 0), quantity FROM order_items WHERE order_id = $1 ORDER BY product_id, variant_id NULLS FIRST`, orderID)
+	if err != nil {
+		return err
+	}
+	var items []domain.OrderItem
+	for rows.Next() {
+		var item domain.OrderItem
+		if err := rows.Scan(&item.ProductID, &item.VariantID, &item.Quantity); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if item.VariantID != 0 {
+			if err := releaseVariantStock(ctx, tx, item); err != nil {
+				return err
+			}
+			continue
+		}
+		var amount int
+		err := tx.QueryRowContext(ctx, `UPDATE products SET amount = amount + $1, version = version + 1 WHERE id = $2 RETURNING amount`,
+			item.Quantity, item.ProductID).Scan(&amount)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := recordStockChange(ctx, tx, item.ProductID, domain.AuditRelease, amount-item.Quantity, amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reserveVariantStock is reserveStock for an item with a variant. The variant
+// is named after the product and its SKU, and sold at its effective price.
+// The product row is locked before the variant's, like every variant write
+// does, so the two cannot deadlock.
+func reserveVariantStock(ctx context.Context, tx *sql.Tx, item *domain.OrderItem) error {
+	if err := bumpProductVersion(ctx, tx, item.ProductID); err != nil {
+		return err
+	}
+	var amount int
+	err := tx.QueryRowContext(ctx,
+		`UPDATE product_variants v SET amount = v.amount - $1
+		 FROM products p
+		 WHERE v.id = $2 AND v.product_id = $3 AND p.id = v.product_id AND p.deleted_at IS NULL AND v.amount >= $1
+		 RETURNING p.name || ' (' || v.sku || ')', p.currency, COALESCE(v.price, p.price), v.amount`,
+		item.Quantity, item.VariantID, item.ProductID).
+		Scan(&item.Name, &item.UnitPrice.Currency, &item.UnitPrice, &amount)
+	if err == nil {
+		return recordVariantStockChange(ctx, tx, item.VariantID, domain.AuditReserve, amount+item.Quantity, amount)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1`+variantFrom+` WHERE v.id = $1 AND v.product_id = $2 AND p.deleted_at IS NULL)`,
+		item.VariantID, item.ProductID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return domain.NewNotFoundError(fmt.Sprintf("variant %d of product %d not found", item.VariantID, item.ProductID))
+	}
+	return domain.NewConflictError(fmt.Sprintf("insufficient stock for variant %d of product %d", item.VariantID, item.ProductID), nil)
+}
+
+// releaseVariantStock returns an item's units to its variant, unless the
+// variant has since been deleted.
+func releaseVariantStock(ctx context.Context, tx *sql.Tx, item domain.OrderItem) error {
+	if err := bumpProductVersion(ctx, tx, item.ProductID); err != nil {
+		return err
+	}
+	var amount int
+	err := tx.QueryRowContext(ctx, `UPDATE product_variants SET amount = amount + $1 WHERE id = $2 RETURNING amount`,
+		item.Quantity, item.VariantID).Scan(&amount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return recordVariantStockChange(ctx, tx, item.VariantID, domain.AuditRelease, amount-item.Quantity, amount)
+}
+
+// recordStockChange audits an order moving a product's stock from one amount to another.
+func recordStockChange(ctx context.Context, tx *sql.Tx, productID int, action string, from, to int) error {
+	return recordAudit(ctx, tx, auditChange{entity: domain.AuditEntityProduct, entityID: productID, action: action,
+		before: map[string]int{"amount": from}, after: map[string]int{"amount": to}})
+}
+
+// recordVariantStockChange is recordStockChange for a variant.
+func recordVariantStockChange(ctx context.Context, tx *sql.Tx, variantID int, action string, from, to int) error {
+	return recordAudit(ctx, tx, auditChange{entity: domain.AuditEntityVariant, entityID: variantID, action: action,
+		before: map[string]int{"amount": from}, after: map[string]int{"amount": to}})
+}
+
+func (r *pgOrderRepository) History(ctx context.Context, id int) ([]domain.OrderTransition, error) {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT order_id, from_status, to_status, actor, note, created_at FROM order_transitions WHERE order_id = $1 ORDER BY id`, id)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing order transition rows: %v", err)
+		}
+	}(rows)
+
+	var history []domain.OrderTransition
+	for rows.Next() {
+		var t domain.OrderTransition
+		if err := rows.Scan(&t.OrderID, &t.From, &t.To, &t.Actor, &t.Note, &t.At); err != nil {
+			return nil, err
+		}
+		history = append(history, t)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	return history, nil
+}
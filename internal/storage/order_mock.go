@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"e-commerce.com/internal/domain"
+)
+
+// MockOrderRepository is an in-memory OrderRepository. Place and
+// Transition reserve and release stock in Products, which must be set, and
+// in Variants for items with a variant.
+type MockOrderRepository struct {
+	Orders      []domain.Order
+	Transitions []domain.OrderTransition
+	Products    *MockProductRepository
+	Variants    *MockVariantRepository
+	Error       error
+}
+
+func (m *MockOrderRepository) Place(_ context.Context, order *domain.Order) error {
+	if m.Error != nil {
+		return m.Error
+	}
+
+	// Check every item before touching stock, so a failure reserves nothing.
+	indexes := make([]int, len(order.Items))
+	variantIndexes := make([]int, len(order.Items))
+	for i, item := range order.Items {
+		idx := m.productIndex(item.ProductID)
+		if idx == -1 || m.Products.Products[idx].DeletedAt != nil {
+			return domain.NewNotFoundError(fmt.Sprintf("product %d not found", item.ProductID))
+		}
+		product := m.Products.Products[idx]
+		indexes[i], variantIndexes[i] = idx, -1
+		if item.VariantID != 0 {
+			vidx := m.variantIndex(item.ProductID, item.VariantID)
+			if vidx == -1 {
+				return domain.NewNotFoundError(fmt.Sprintf("variant %d of product %d not found", item.VariantID, item.ProductID))
+			}
+			variant := m.Variants.Variants[vidx]
+			if variant.Amount < item.Quantity {
+				return domain.NewConflictError(fmt.Sprintf("insufficient stock for variant %d of product %d", item.VariantID, item.ProductID), nil)
+			}
+			order.Items[i].Name = product.Name + " (" + variant.SKU + ")"
+			order.Items[i].UnitPrice = variant.EffectivePrice(product)
+			variantIndexes[i] = vidx
+			continue
+		}
+		if product.Amount < item.Quantity {
+			return domain.NewConflictError(fmt.Sprintf("insufficient stock for product %d", item.ProductID), nil)
+		}
+		order.Items[i].Name = product.Name
+		order.Items[i].UnitPrice = product.Price
+	}
+	if err := order.UpdateTotal(); err != nil {
+		return err
+	}
+	for i, item := range order.Items {
+		if variantIndexes[i] != -1 {
+			m.Variants.Variants[variantIndexes[i]].Amount -= item.Quantity
+		} else {
+			m.Products.Products[indexes[i]].Amount -= item.Quantity
+		}
+		m.Products.Products[indexes[i]].Version++
+	}
+
+	order.ID = len(m.Orders) + 1
+	order.CreatedAt = time.Now()
+	order.UpdatedAt = order.CreatedAt
+	m.Orders = append(m.Orders, *order)
+	return nil
+}
+
+func (m *MockOrderRepository) FindByID(_ context.Context, id int) (domain.Order, error) {
+	if m.Error != nil {
+		return domain.Order{}, m.Error
+	}
+	for _, o := range m.Orders {
+		if o.ID == id {
+			return o, nil
+		}
+	}
+	return domain.Order{}, domain.NewNotFoundError("order not found")
+}
+
+func (m *MockOrderRepository) FindByUser(_ context.Context, userID int) ([]domain.Order, error) {
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	var orders []domain.Order
+	for i := len(m.Orders) - 1; i >= 0; i-- {
+		if o := m.Orders[i]; o.UserID != nil && *o.UserID == userID {
+			orders = append(orders, o)
+		}
+	}
+	return orders, nil
+}
+
+func (m *MockOrderRepository) Transition(_ context.Context, id int, to domain.OrderStatus, actor, note string) (domain.Order, error) {
+	if m.Error != nil {
+		return domain.Order{}, m.Error
+	}
+	for i := range m.Orders {
+		if m.Orders[i].ID != id {
+			continue
+		}
+		t, err := m.Orders[i].Transition(to, actor, note, time.Now())
+		if err != nil {
+			return domain.Order{}, err
+		}
+		m.Transitions = append(m.Transitions, t)
+		if domain.ReleasesStock(t.From, t.To) {
+			for _, item := range m.Orders[i].Items {
+				m.release(item)
+			}
+		}
+		return m.Orders[i], nil
+	}
+	return domain.Order{}, domain.NewNotFoundError("order not found")
+}
+
+func (m *MockOrderRepository) History(_ context.Context, id int) ([]domain.OrderTransition, error) {
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	var history []domain.OrderTransition
+	for _, t := range m.Transitions {
+		if t.OrderID == id {
+			history = append(history, t)
+		}
+	}
+	return history, nil
+}
+
+// release returns an item's units to its product or variant, unless it has
+// since been deleted.
+func (m *MockOrderRepository) release(item domain.OrderItem) {
+	idx := m.productIndex(item.ProductID)
+	if idx == -1 {
+		return
+	}
+	if item.VariantID != 0 {
+		vidx := m.variantIndex(item.ProductID, item.VariantID)
+		if vidx == -1 {
+			return
+		}
+		m.Variants.Variants[vidx].Amount += item.Quantity
+	} else {
+		m.Products.Products[idx].Amount += item.Quantity
+	}
+	m.Products.Products[idx].Version++
+}
+
+func (m *MockOrderRepository) variantIndex(productID, id int) int {
+	if m.Variants == nil {
+		return -1
+	}
+	return m.Variants.indexOf(productID, id)
+}
+
+func (m *MockOrderRepository) productIndex(id int) int {
+	for i, p := range m.Products.Products {
+		if p.ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"e-commerce.com/internal/domain"
+)
+
+// pgPaymentRepository implements the PaymentRepository interface for PostgreSQL.
+type pgPaymentRepository struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+// NewPaymentRepository creates a new instance of the payment repository.
+func NewPaymentRepository(db *sql.DB, queryTimeout time.Duration) domain.PaymentRepository {
+	return &pgPaymentRepository{db: db, queryTimeout: queryTimeout}
+}
+
+const paymentColumns = "id, order_id, provider, provider_ref, status, currency, amount, failure_reason, created_at, updated_at"
+
+func scanPayment(row interface{ Scan(...interface{}) error }) (domain.Payment, error) {
+	var p domain.Payment
+	err := row.Scan(&p.ID, &p.OrderID, &p.Provider, &p.ProviderRef, &p.Status,
+		&p.Amount.Currency, &p.Amount, &p.FailureReason, &p.CreatedAt, &p.UpdatedAt)
+	return p, err
+}
+
+func (r *pgPaymentRepository) Save(ctx context.Context, payment *domain.Payment) error {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO payments (order_id, provider, provider_ref, status, currency, amount, failure_reason)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at, updated_at`,
+		payment.OrderID, payment.Provider, payment.ProviderRef, payment.Status, payment.Amount.Currency, payment.Amount, payment.FailureReason).
+		Scan(&payment.ID, &payment.CreatedAt, &payment.UpdatedAt)
+	if isForeignKeyViolation(err) {
+		return domain.NewNotFoundError("order not found")
+	}
+	if uniqueViolationOn(err, "payments_order_authorized_key") {
+		return domain.NewConflictError("order already has an authorized payment", err)
+	}
+	return translateError(err)
+}
+
+func (r *pgPaymentRepository) FindByID(ctx context.Context, id int) (domain.Payment, error) {
+	return r.findOne(ctx, "id = $1", id)
+}
+
+func (r *pgPaymentRepository) FindByProviderRef(ctx context.Context, provider, ref string) (domain.Payment, error) {
+	return r.findOne(ctx, "provider = $1 AND provider_ref = $2", provider, ref)
+}
+
+func (r *pgPaymentRepository) findOne(ctx context.Context, condition string, args ...interface{}) (domain.Payment, error) {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	p, err := scanPayment(r.db.QueryRowContext(ctx, "SELECT "+paymentColumns+" FROM payments WHERE "+condition, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Payment{}, domain.NewNotFoundError("payment not found")
+		}
+		return domain.Payment{}, translateError(err)
+	}
+	return p, nil
+}
+
+func (r *pgPaymentRepository) FindByOrder(ctx context.Context, orderID int) ([]domain.Payment, error) {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+paymentColumns+" FROM payments WHERE order_id = $1 ORDER BY id", orderID)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing payment rows: %v", err)
+		}
+	}(rows)
+
+	var payments []domain.Payment
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	return payments, nil
+}
+
+// UpdateStatus is a compare-and-set on the status column, so a webhook and an
+// API call racing on the same payment cannot both apply.
+func (r *pgPaymentRepository) UpdateStatus(ctx context.Context, id int, from, to domain.PaymentStatus, reason string) (domain.Payment, error) {
+	if err := domain.CheckPaymentTransition(from, to); err != nil {
+		return domain.Payment{}, err
+	}
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var p domain.Payment
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		var err error
+		p, err = scanPayment(tx.QueryRowContext(ctx,
+			`UPDATE payments SET status = $1, failure_reason = $2, updated_at = now() WHERE id = $3 AND status = $4 RETURNING `+paymentColumns,
+			to, reason, id, from))
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.NewConflictError("payment status has changed", nil)
+		}
+		if err != nil {
+			return err
+		}
+
+		orderStatus, ok := to.SettlesOrder()
+		if !ok {
+			return nil
+		}
+		return transitionOrder(ctx, tx, p.OrderID, orderStatus, "payment:"+p.Provider, "payment "+strconv.Itoa(p.ID)+" "+string(to))
+	})
+	if err != nil {
+		return domain.Payment{}, err
+	}
+	return p, nil
+}
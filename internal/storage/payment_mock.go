@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"e-commerce.com/internal/domain"
+)
+
+// MockPaymentRepository is an in-memory PaymentRepository. Orders, when set,
+// receives the order transitions of payments that settle their order.
+type MockPaymentRepository struct {
+	Payments []domain.Payment
+	Orders   *MockOrderRepository
+	Error    error
+}
+
+func (m *MockPaymentRepository) Save(_ context.Context, payment *domain.Payment) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	if payment.Status == domain.PaymentStatusAuthorized {
+		// Mirrors the one-open-authorization-per-order index.
+		for _, p := range m.Payments {
+			if p.OrderID == payment.OrderID && p.Status == domain.PaymentStatusAuthorized {
+				return domain.NewConflictError("order already has an authorized payment", nil)
+			}
+		}
+	}
+	payment.ID = len(m.Payments) + 1
+	payment.CreatedAt = time.Now()
+	payment.UpdatedAt = payment.CreatedAt
+	m.Payments = append(m.Payments, *payment)
+	return nil
+}
+
+func (m *MockPaymentRepository) FindByID(_ context.Context, id int) (domain.Payment, error) {
+	return m.find(func(p domain.Payment) bool { return p.ID == id })
+}
+
+func (m *MockPaymentRepository) FindByProviderRef(_ context.Context, provider, ref string) (domain.Payment, error) {
+	return m.find(func(p domain.Payment) bool { return p.Provider == provider && p.ProviderRef == ref })
+}
+
+func (m *MockPaymentRepository) FindByOrder(_ context.Context, orderID int) ([]domain.Payment, error) {
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	var payments []domain.Payment
+	for _, p := range m.Payments {
+		if p.OrderID == orderID {
+			payments = append(payments, p)
+		}
+	}
+	return payments, nil
+}
+
+func (m *MockPaymentRepository) UpdateStatus(ctx context.Context, id int, from, to domain.PaymentStatus, reason string) (domain.Payment, error) {
+	if m.Error != nil {
+		return domain.Payment{}, m.Error
+	}
+	if err := domain.CheckPaymentTransition(from, to); err != nil {
+		return domain.Payment{}, err
+	}
+	for i := range m.Payments {
+		if m.Payments[i].ID != id {
+			continue
+		}
+		if m.Payments[i].Status != from {
+			return domain.Payment{}, domain.NewConflictError("payment status has changed", nil)
+		}
+		if orderStatus, ok := to.SettlesOrder(); ok && m.Orders != nil {
+			p := m.Payments[i]
+			if _, err := m.Orders.Transition(ctx, p.OrderID, orderStatus,
+				"payment:"+p.Provider, "payment "+strconv.Itoa(p.ID)+" "+string(to)); err != nil {
+				return domain.Payment{}, err
+			}
+		}
+		m.Payments[i].Status = to
+		m.Payments[i].FailureReason = reason
+		m.Payments[i].UpdatedAt = time.Now()
+		return m.Payments[i], nil
+	}
+	return domain.Payment{}, domain.NewNotFoundError("payment not found")
+}
+
+func (m *MockPaymentRepository) find(match func(domain.Payment) bool) (domain.Payment, error) {
+	if m.Error != nil {
+		return domain.Payment{}, m.Error
+	}
+	for _, p := range m.Payments {
+		if match(p) {
+			return p, nil
+		}
+	}
+	return domain.Payment{}, domain.NewNotFoundError("payment not found")
+}
@@ -1,33 +1,140 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"e-commerce.com/internal/domain"
+
+	"github.com/lib/pq"
 )
 
 // pgProductRepository implements the ProductRepository interface for PostgreSQL.
 type pgProductRepository struct {
-	db *sql.DB
+	db           *sql.DB
+	queryTimeout time.Duration
 }
 
 // NewProductRepository creates a new instance of the product repository.
-func NewProductRepository(db *sql.DB) domain.ProductRepository {
-	return &pgProductRepository{db: db}
+// Every query is cancelled after queryTimeout; zero disables the limit.
+func NewProductRepository(db *sql.DB, queryTimeout time.Duration) domain.ProductRepository {
+	return &pgProductRepository{db: db, queryTimeout: queryTimeout}
 }
 
-func (r *pgProductRepository) Save(product *domain.Product) error {
-	sqlStatement := `INSERT INTO products (name, price, amount, description) VALUES ($1, $2, $3, $4) RETURNING id`
-	return r.db.QueryRow(sqlStatement, product.Name, product.Price, product.Amount, product.Description).Scan(&product.ID)
+func (r *pgProductRepository) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, r.queryTimeout)
 }
 
-// FindAll now accepts page and limit, and returns the product slice, total count, and an error.
-func (r *pgProductRepository) FindAll(page, limit int) ([]domain.Product, int, error) {
-	var total int
-	// First, get the total count of products.
-	err := r.db.QueryRow("SELECT COUNT(*) FROM products").Scan(&total)
+// maxSlugAttempts bounds how often Save retries a generated slug that a
+// concurrent insert took first.
+const maxSlugAttempts = 3
+
+func (r *pgProductRepository) Save(ctx context.Context, product *domain.Product) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	generated := product.Slug == ""
+	for attempt := 1; ; attempt++ {
+		err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+			return r.save(ctx, tx, product)
+		})
+		if generated && attempt < maxSlugAttempts && uniqueViolationOn(err, "products_slug_key") {
+			continue
+		}
+		return err
+	}
+}
+
+// save inserts product and records its creation. A failed insert aborts tx,
+// so retrying a generated slug that was taken meanwhile is up to the caller.
+func (r *pgProductRepository) save(ctx context.Context, tx *sql.Tx, product *domain.Product) error {
+	generated := product.Slug == ""
+	if generated {
+		slug, err := r.freeSlug(ctx, tx, domain.Slugify(product.Name))
+		if err != nil {
+			return err
+		}
+		product.Slug = slug
+	}
+	sqlStatement := `INSERT INTO products (name, currency, price, amount, description, sku, slug) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7) RETURNING ` + productColumns
+	err := scanProduct(tx.QueryRowContext(ctx, sqlStatement, product.Name, product.Price.Currency, product.Price, product.Amount,
+		product.Description, product.SKU, product.Slug), product)
+	if err != nil {
+		if generated {
+			product.Slug = ""
+		}
+		return translateProductError(err)
+	}
+	return recordAudit(ctx, tx, auditChange{entity: domain.AuditEntityProduct, entityID: product.ID, action: domain.AuditCreate, after: *product})
+}
+
+// freeSlug returns the first of base, base-2, base-3, ... no product uses yet.
+// Deleted products count too, so generated slugs never stand in the way of a
+// restore.
+func (r *pgProductRepository) freeSlug(ctx context.Context, q queryer, base string) (string, error) {
+	rows, err := q.QueryContext(ctx, `SELECT slug FROM products WHERE slug = $1 OR slug LIKE $2 ESCAPE '\'`,
+		base, likeEscaper.Replace(base)+"-%")
+	if err != nil {
+		return "", translateError(err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing slug rows: %v", err)
+		}
+	}(rows)
+
+	taken := make(map[string]bool)
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return "", err
+		}
+		taken[slug] = true
+	}
+	if err := rows.Err(); err != nil {
+		return "", translateError(err)
+	}
+	for n := 1; ; n++ {
+		if candidate := domain.SlugCandidate(base, n); !taken[candidate] {
+			return candidate, nil
+		}
+	}
+}
+
+// translateProductError reports SKU and slug clashes as conflicts that name
+// the offending field.
+func translateProductError(err error) error {
+	switch {
+	case uniqueViolationOn(err, "products_sku_key"):
+		return domain.NewConflictError("sku already exists", err)
+	case uniqueViolationOn(err, "products_slug_key"):
+		return domain.NewConflictError("slug already exists", err)
+	}
+	return translateError(err)
+}
+
+// productColumns is the column list every product query selects, in the order scanProduct expects.
+const productColumns = "id, name, currency, price, amount, description, COALESCE(sku, ''), slug, version, deleted_at"
+
+// scanProduct scans productColumns into p, followed by any extra columns.
+func scanProduct(row interface{ Scan(...interface{}) error }, p *domain.Product, extra ...interface{}) error {
+	dest := []interface{}{&p.ID, &p.Name, &p.Price.Currency, &p.Price, &p.Amount, &p.Description, &p.SKU, &p.Slug, &p.Version, &p.DeletedAt}
+	return row.Scan(append(dest, extra...)...)
+}
+
+// FindAll accepts a filter, page and limit, and returns the product slice, total count, and an error.
+func (r *pgProductRepository) FindAll(ctx context.Context, filter domain.ProductFilter, page, limit int) ([]domain.Product, int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	// First, get the total count of matching products.
+	total, err := r.Count(ctx, filter)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -36,73 +143,307 @@ func (r *pgProductRepository) FindAll(page, limit int) ([]domain.Product, int, e
 	offset := (page - 1) * limit
 
 	// Now, fetch the products for the specific page.
-	rows, err := r.db.Query("SELECT id, name, price, amount, description FROM products ORDER BY id ASC LIMIT $1 OFFSET $2", limit, offset)
+	conditions, args := productConditions(filter)
+	query := fmt.Sprintf("SELECT %s FROM products%s%s LIMIT $%d OFFSET $%d",
+		productColumns, whereClause(conditions), productOrderClause(filter.Sort, false), len(args)+1, len(args)+2)
+	products, err := queryProducts(ctx, r.db, query, append(args, limit, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
+
+	return products, total, nil
+}
+
+// FindPage implements keyset pagination: instead of an OFFSET it seeks to the
+// cursor's sort key, so the cost does not grow with the page number and rows
+// inserted meanwhile cannot shift the page boundaries.
+func (r *pgProductRepository) FindPage(ctx context.Context, filter domain.ProductFilter, cursor *domain.ProductCursor, limit int) (domain.ProductPage, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	conditions, args := productConditions(filter)
+	backward := cursor != nil && cursor.Before
+	if cursor != nil {
+		condition, cursorArgs := cursorCondition(*cursor, len(args))
+		conditions = append(conditions, condition)
+		args = append(args, cursorArgs...)
+	}
+
+	// Fetch one extra row to learn whether another page follows.
+	query := fmt.Sprintf("SELECT %s FROM products%s%s LIMIT $%d",
+		productColumns, whereClause(conditions), productOrderClause(filter.Sort, backward), len(args)+1)
+	products, err := queryProducts(ctx, r.db, query, append(args, limit+1)...)
+	if err != nil {
+		return domain.ProductPage{}, err
+	}
+
+	return newProductPage(products, cursor, limit), nil
+}
+
+// Count returns how many products match the filter.
+func (r *pgProductRepository) Count(ctx context.Context, filter domain.ProductFilter) (int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	conditions, args := productConditions(filter)
+	var total int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM products"+whereClause(conditions), args...).Scan(&total)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return total, nil
+}
+
+// queryProducts runs a query selecting productColumns through q and scans every row.
+func queryProducts(ctx context.Context, q queryer, query string, args ...interface{}) ([]domain.Product, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
 		if err != nil {
-			log.Printf("Error closing rows on FindAll: %v", err)
+			log.Printf("Error closing product rows: %v", err)
 		}
 	}(rows)
 
 	var products []domain.Product
 	for rows.Next() {
 		var p domain.Product
-		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Amount, &p.Description); err != nil {
-			return nil, 0, err
+		if err := scanProduct(rows, &p); err != nil {
+			return nil, err
 		}
 		products = append(products, p)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, 0, err
+		return nil, translateError(err)
 	}
+	return products, nil
+}
 
-	return products, total, nil
+// newProductPage trims the extra row fetched by FindPage and works out which
+// neighbouring pages exist. Backward pages are fetched in reverse order and
+// flipped back here.
+func newProductPage(products []domain.Product, cursor *domain.ProductCursor, limit int) domain.ProductPage {
+	more := len(products) > limit
+	if more {
+		products = products[:limit]
+	}
+	page := domain.ProductPage{Products: products}
+	if cursor != nil && cursor.Before {
+		for i, j := 0, len(products)-1; i < j; i, j = i+1, j-1 {
+			products[i], products[j] = products[j], products[i]
+		}
+		page.HasPrev = more
+		page.HasNext = true
+	} else {
+		page.HasNext = more
+		page.HasPrev = cursor != nil
+	}
+	return page
+}
+
+// whereClause joins conditions into a WHERE clause with a leading space, or returns "".
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// productConditions translates a filter into SQL conditions and their
+// positional arguments.
+func productConditions(filter domain.ProductFilter) ([]string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	if filter.Deleted {
+		conditions[0] = "deleted_at IS NOT NULL"
+	}
+	var args []interface{}
+	if len(filter.CategoryIDs) > 0 {
+		args = append(args, pq.Array(filter.CategoryIDs))
+		conditions = append(conditions, fmt.Sprintf(
+			"id IN (SELECT product_id FROM product_categories WHERE category_id = ANY($%d))", len(args)))
+	}
+	if filter.Name != "" {
+		args = append(args, "%"+likeEscaper.Replace(filter.Name)+"%")
+		conditions = append(conditions, fmt.Sprintf(`name ILIKE $%d ESCAPE '\'`, len(args)))
+	}
+	if filter.MinPrice != nil {
+		args = append(args, *filter.MinPrice)
+		conditions = append(conditions, fmt.Sprintf("price >= $%d", len(args)))
+	}
+	if filter.MaxPrice != nil {
+		args = append(args, *filter.MaxPrice)
+		conditions = append(conditions, fmt.Sprintf("price <= $%d", len(args)))
+	}
+	if filter.InStock {
+		conditions = append(conditions, "amount > 0")
+	}
+	return conditions, args
+}
+
+// cursorCondition returns the condition selecting rows after (or before) the
+// cursor position. Placeholders are numbered after the argOffset existing ones.
+func cursorCondition(cursor domain.ProductCursor, argOffset int) (string, []interface{}) {
+	op := ">"
+	if cursor.Sort.Descending != cursor.Before {
+		op = "<"
+	}
+	column, ok := productSortColumns[cursor.Sort.Field]
+	if !ok || column == "id" {
+		return fmt.Sprintf("id %s $%d", op, argOffset+1), []interface{}{cursor.ID}
+	}
+	return fmt.Sprintf("(%s, id) %s ($%d::%s, $%d)", column, op, argOffset+1, productSortTypes[column], argOffset+2),
+		[]interface{}{cursor.Value, cursor.ID}
+}
+
+// productSortColumns whitelists the columns a listing can be ordered by; user
+// input never reaches the SQL text directly.
+var productSortColumns = map[string]string{
+	domain.ProductSortID:     "id",
+	domain.ProductSortName:   "name",
+	domain.ProductSortPrice:  "price",
+	domain.ProductSortAmount: "amount",
+}
+
+// productSortTypes gives the SQL type used to compare cursor values with each sort column.
+var productSortTypes = map[string]string{
+	"name":   "text",
+	"price":  "numeric",
+	"amount": "integer",
+}
+
+// likeEscaper escapes the LIKE wildcards in user-supplied search text.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// productOrderClause translates a sort into an ORDER BY clause with a leading
+// space. reverse flips the direction, which backward keyset pages need.
+func productOrderClause(sort domain.ProductSort, reverse bool) string {
+	column, ok := productSortColumns[sort.Field]
+	if !ok {
+		column = "id"
+	}
+	direction := "ASC"
+	if sort.Descending != reverse {
+		direction = "DESC"
+	}
+	if column == "id" {
+		return " ORDER BY id " + direction
+	}
+	return fmt.Sprintf(" ORDER BY %s %s, id %s", column, direction, direction)
+}
+
+func (r *pgProductRepository) FindByID(ctx context.Context, id int) (domain.Product, error) {
+	return r.findOne(ctx, "id", id)
+}
+
+func (r *pgProductRepository) FindBySKU(ctx context.Context, sku string) (domain.Product, error) {
+	return r.findOne(ctx, "sku", sku)
+}
+
+func (r *pgProductRepository) FindBySlug(ctx context.Context, slug string) (domain.Product, error) {
+	return r.findOne(ctx, "slug", slug)
 }
 
-func (r *pgProductRepository) FindByID(id int) (domain.Product, error) {
-	row := r.db.QueryRow("SELECT id, name, price, amount, description FROM products WHERE id = $1", id)
+// findOne loads the product whose unique column equals value. column is
+// always a constant from this file.
+func (r *pgProductRepository) findOne(ctx context.Context, column string, value interface{}) (domain.Product, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return queryProduct(ctx, r.db, column, value)
+}
+
+// queryProduct loads the product whose unique column equals value through q.
+func queryProduct(ctx context.Context, q queryer, column string, value interface{}) (domain.Product, error) {
 	var p domain.Product
-	err := row.Scan(&p.ID, &p.Name, &p.Price, &p.Amount, &p.Description)
+	err := scanProduct(q.QueryRowContext(ctx, "SELECT "+productColumns+" FROM products WHERE "+column+" = $1 AND deleted_at IS NULL", value), &p)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return domain.Product{}, errors.New("product not found")
+			return domain.Product{}, domain.NewNotFoundError("product not found")
 		}
-		return domain.Product{}, err
+		return domain.Product{}, translateError(err)
 	}
 	return p, nil
 }
 
-func (r *pgProductRepository) Update(product *domain.Product) error {
-	sqlStatement := `UPDATE products SET name=$1, price=$2, amount=$3, description=$4 WHERE id=$5`
-	res, err := r.db.Exec(sqlStatement, product.Name, product.Price, product.Amount, product.Description, product.ID)
+func (r *pgProductRepository) Update(ctx context.Context, product *domain.Product) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return update(ctx, tx, product)
+	})
+}
+
+func update(ctx context.Context, tx *sql.Tx, product *domain.Product) error {
+	before, err := lockProduct(ctx, tx, product.ID, product.Version)
 	if err != nil {
 		return err
 	}
-	rowsAffected, err := res.RowsAffected()
-	if err != nil {
-		return err
+	if product.Price.Currency != before.Price.Currency {
+		// Variant price overrides are stored in the product's currency.
+		var overridden bool
+		err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM product_variants WHERE product_id = $1 AND price IS NOT NULL)`,
+			product.ID).Scan(&overridden)
+		if err != nil {
+			return err
+		}
+		if overridden {
+			return domain.NewConflictError("cannot change the currency of a product whose variants override its price", nil)
+		}
 	}
-	if rowsAffected == 0 {
-		return errors.New("product not found for update")
+	var after domain.Product
+	sqlStatement := `UPDATE products SET name=$1, currency=$2, price=$3, amount=$4, description=$5, sku=NULLIF($6, ''), slug=COALESCE(NULLIF($7, ''), slug),
+		version=version+1 WHERE id=$8 RETURNING ` + productColumns
+	err = scanProduct(tx.QueryRowContext(ctx, sqlStatement, product.Name, product.Price.Currency, product.Price, product.Amount,
+		product.Description, product.SKU, product.Slug, product.ID), &after)
+	if err != nil {
+		return translateProductError(err)
 	}
-	return nil
+	product.Slug, product.Version = after.Slug, after.Version
+	return recordAudit(ctx, tx, auditChange{entity: domain.AuditEntityProduct, entityID: product.ID, action: domain.AuditUpdate, before: before, after: after})
 }
 
-func (r *pgProductRepository) Delete(id int) error {
-	res, err := r.db.Exec(`DELETE FROM products WHERE id = $1`, id)
+func (r *pgProductRepository) Delete(ctx context.Context, id, expectedVersion int) error {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		return deleteProduct(ctx, tx, id, expectedVersion)
+	})
+}
+
+func deleteProduct(ctx context.Context, tx *sql.Tx, id, expectedVersion int) error {
+	before, err := lockProduct(ctx, tx, id, expectedVersion)
 	if err != nil {
 		return err
 	}
-	rowsAffected, err := res.RowsAffected()
+	var after domain.Product
+	err = scanProduct(tx.QueryRowContext(ctx, `UPDATE products SET deleted_at = now(), version = version + 1
+		WHERE id = $1 RETURNING `+productColumns, id), &after)
 	if err != nil {
-		return err
+		return translateError(err)
 	}
-	if rowsAffected == 0 {
-		return errors.New("product not found for deletion")
+	return recordAudit(ctx, tx, auditChange{entity: domain.AuditEntityProduct, entityID: id, action: domain.AuditDelete, before: before, after: after})
+}
+
+// lockProduct reads the live product a versioned write is about to change
+// and locks its row until tx ends. It fails with not found when there is no
+// such product, and with a precondition error when expectedVersion is set
+// and the version moved on.
+func lockProduct(ctx context.Context, tx *sql.Tx, id, expectedVersion int) (domain.Product, error) {
+	var p domain.Product
+	err := scanProduct(tx.QueryRowContext(ctx, "SELECT "+productColumns+" FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id), &p)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Product{}, domain.NewNotFoundError("product not found")
 	}
-	return nil
+	if err != nil {
+		return domain.Product{}, translateError(err)
+	}
+	if expectedVersion != 0 && p.Version != expectedVersion {
+		return domain.Product{}, domain.NewPreconditionFailedError("product has been modified since it was read")
+	}
+	return p, nil
 }
@@ -1,30 +1,72 @@
 package storage
 
 import (
-	"fmt"
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"e-commerce.com/internal/domain"
 )
 
 type MockProductRepository struct {
 	Products []domain.Product
-	Error    error
+	// Categories maps a product ID to the IDs of the categories it is assigned to.
+	Categories map[int][]int
+	Error      error
 }
 
-func (m *MockProductRepository) Save(product *domain.Product) error {
+func (m *MockProductRepository) Save(_ context.Context, product *domain.Product) error {
 	if m.Error != nil {
 		return m.Error
 	}
+	if product.Slug == "" {
+		base := domain.Slugify(product.Name)
+		for n := 1; ; n++ {
+			if candidate := domain.SlugCandidate(base, n); m.indexBy(func(p domain.Product) bool { return p.Slug == candidate }) == -1 {
+				product.Slug = candidate
+				break
+			}
+		}
+	}
+	if err := m.checkUnique(product); err != nil {
+		return err
+	}
 	product.ID = len(m.Products) + 1
+	product.Version = 1
 	m.Products = append(m.Products, *product)
 	return nil
 }
 
-func (m *MockProductRepository) FindAll(page, limit int) ([]domain.Product, int, error) {
+// checkUnique mirrors the SKU and slug unique indexes, which only cover live products.
+func (m *MockProductRepository) checkUnique(product *domain.Product) error {
+	live := func(p domain.Product) bool { return p.ID != product.ID && p.DeletedAt == nil }
+	if product.SKU != "" && m.indexBy(func(p domain.Product) bool { return p.SKU == product.SKU && live(p) }) != -1 {
+		return domain.NewConflictError("sku already exists", nil)
+	}
+	if product.Slug != "" && m.indexBy(func(p domain.Product) bool { return p.Slug == product.Slug && live(p) }) != -1 {
+		return domain.NewConflictError("slug already exists", nil)
+	}
+	return nil
+}
+
+func (m *MockProductRepository) indexBy(match func(domain.Product) bool) int {
+	for i, p := range m.Products {
+		if match(p) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *MockProductRepository) FindAll(_ context.Context, filter domain.ProductFilter, page, limit int) ([]domain.Product, int, error) {
 	if m.Error != nil {
 		return nil, 0, m.Error
 	}
-	total := len(m.Products)
+	matched := m.filtered(filter)
+
+	total := len(matched)
 	start := (page - 1) * limit
 	end := start + limit
 	if start > total {
@@ -33,48 +75,264 @@ func (m *MockProductRepository) FindAll(page, limit int) ([]domain.Product, int,
 	if end > total {
 		end = total
 	}
-	return m.Products[start:end], total, nil
+	return matched[start:end], total, nil
 }
 
-func (m *MockProductRepository) FindByID(id int) (domain.Product, error) {
+func (m *MockProductRepository) FindPage(_ context.Context, filter domain.ProductFilter, cursor *domain.ProductCursor, limit int) (domain.ProductPage, error) {
 	if m.Error != nil {
-		return domain.Product{}, m.Error
+		return domain.ProductPage{}, m.Error
+	}
+	matched := m.filtered(filter)
+	if cursor != nil && cursor.Before {
+		for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+			matched[i], matched[j] = matched[j], matched[i]
+		}
 	}
+
+	var products []domain.Product
+	for _, p := range matched {
+		if cursor != nil && !afterCursor(p, *cursor) {
+			continue
+		}
+		products = append(products, p)
+		if len(products) > limit {
+			break
+		}
+	}
+	return newProductPage(products, cursor, limit), nil
+}
+
+func (m *MockProductRepository) Count(_ context.Context, filter domain.ProductFilter) (int, error) {
+	if m.Error != nil {
+		return 0, m.Error
+	}
+	return len(m.filtered(filter)), nil
+}
+
+// filtered returns a sorted copy of the products matching filter.
+func (m *MockProductRepository) filtered(filter domain.ProductFilter) []domain.Product {
+	var matched []domain.Product
 	for _, p := range m.Products {
-		if p.ID == id {
-			return p, nil
+		if m.matches(p, filter) {
+			matched = append(matched, p)
+		}
+	}
+	sortProducts(matched, filter.Sort)
+	return matched
+}
+
+// afterCursor reports whether p lies beyond the cursor in its direction of travel.
+func afterCursor(p domain.Product, cursor domain.ProductCursor) bool {
+	cmp := 0
+	switch cursor.Sort.Field {
+	case domain.ProductSortName:
+		cmp = strings.Compare(p.Name, cursor.Value)
+	case domain.ProductSortPrice:
+		value, _ := domain.ParseMoney(cursor.Value, p.Price.Currency)
+		cmp = compareInts(p.Price.Amount, value.Amount)
+	case domain.ProductSortAmount:
+		value, _ := strconv.Atoi(cursor.Value)
+		cmp = compareInts(int64(p.Amount), int64(value))
+	}
+	if cmp == 0 {
+		cmp = compareInts(int64(p.ID), int64(cursor.ID))
+	}
+	if cursor.Sort.Descending != cursor.Before {
+		return cmp < 0
+	}
+	return cmp > 0
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// matches applies filter with the same semantics as the PostgreSQL repository.
+func (m *MockProductRepository) matches(p domain.Product, filter domain.ProductFilter) bool {
+	if (p.DeletedAt != nil) != filter.Deleted {
+		return false
+	}
+	if len(filter.CategoryIDs) > 0 && !containsAny(m.Categories[p.ID], filter.CategoryIDs) {
+		return false
+	}
+	if filter.Name != "" && !strings.Contains(strings.ToLower(p.Name), strings.ToLower(filter.Name)) {
+		return false
+	}
+	if filter.MinPrice != nil && p.Price.Amount < filter.MinPrice.Amount {
+		return false
+	}
+	if filter.MaxPrice != nil && p.Price.Amount > filter.MaxPrice.Amount {
+		return false
+	}
+	if filter.InStock && p.Amount <= 0 {
+		return false
+	}
+	return true
+}
+
+// sortProducts orders products in place like productOrderClause does in SQL.
+func sortProducts(products []domain.Product, order domain.ProductSort) {
+	sort.SliceStable(products, func(i, j int) bool {
+		a, b := products[i], products[j]
+		if order.Descending {
+			a, b = b, a
+		}
+		switch order.Field {
+		case domain.ProductSortName:
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+		case domain.ProductSortPrice:
+			if a.Price.Amount != b.Price.Amount {
+				return a.Price.Amount < b.Price.Amount
+			}
+		case domain.ProductSortAmount:
+			if a.Amount != b.Amount {
+				return a.Amount < b.Amount
+			}
+		}
+		return a.ID < b.ID
+	})
+}
+
+func containsAny(values, candidates []int) bool {
+	for _, v := range values {
+		for _, c := range candidates {
+			if v == c {
+				return true
+			}
 		}
 	}
-	return domain.Product{}, fmt.Errorf("product not found")
+	return false
 }
 
-func (m *MockProductRepository) Update(product *domain.Product) error {
+func (m *MockProductRepository) FindByID(_ context.Context, id int) (domain.Product, error) {
+	if m.Error != nil {
+		return domain.Product{}, m.Error
+	}
+	if idx := m.liveIndex(id); idx != -1 {
+		return m.Products[idx], nil
+	}
+	return domain.Product{}, domain.NewNotFoundError("product not found")
+}
+
+// liveIndex returns the index of the product with id unless it is missing or deleted.
+func (m *MockProductRepository) liveIndex(id int) int {
+	return m.indexBy(func(p domain.Product) bool { return p.ID == id && p.DeletedAt == nil })
+}
+
+func (m *MockProductRepository) FindBySKU(_ context.Context, sku string) (domain.Product, error) {
+	if m.Error != nil {
+		return domain.Product{}, m.Error
+	}
+	if idx := m.indexBy(func(p domain.Product) bool { return p.SKU == sku && p.DeletedAt == nil }); idx != -1 {
+		return m.Products[idx], nil
+	}
+	return domain.Product{}, domain.NewNotFoundError("product not found")
+}
+
+func (m *MockProductRepository) FindBySlug(_ context.Context, slug string) (domain.Product, error) {
+	if m.Error != nil {
+		return domain.Product{}, m.Error
+	}
+	if idx := m.indexBy(func(p domain.Product) bool { return p.Slug == slug && p.DeletedAt == nil }); idx != -1 {
+		return m.Products[idx], nil
+	}
+	return domain.Product{}, domain.NewNotFoundError("product not found")
+}
+
+func (m *MockProductRepository) Update(_ context.Context, product *domain.Product) error {
 	if m.Error != nil {
 		return m.Error
 	}
-	for i, p := range m.Products {
-		if p.ID == product.ID {
-			m.Products[i] = *product
-			return nil
-		}
+	i := m.liveIndex(product.ID)
+	if i == -1 {
+		return domain.NewNotFoundError("product not found")
+	}
+	if product.Version != 0 && product.Version != m.Products[i].Version {
+		return domain.NewPreconditionFailedError("product has been modified since it was read")
+	}
+	if product.Slug == "" {
+		product.Slug = m.Products[i].Slug
 	}
-	return fmt.Errorf("product not found for update")
+	if err := m.checkUnique(product); err != nil {
+		return err
+	}
+	product.Version = m.Products[i].Version + 1
+	product.DeletedAt = nil
+	m.Products[i] = *product
+	return nil
 }
 
-func (m *MockProductRepository) Delete(id int) error {
+func (m *MockProductRepository) Delete(_ context.Context, id, expectedVersion int) error {
 	if m.Error != nil {
 		return m.Error
 	}
-	idx := -1
-	for i, p := range m.Products {
-		if p.ID == id {
-			idx = i
-			break
+	idx := m.liveIndex(id)
+	if idx == -1 {
+		return domain.NewNotFoundError("product not found")
+	}
+	if expectedVersion != 0 && expectedVersion != m.Products[idx].Version {
+		return domain.NewPreconditionFailedError("product has been modified since it was read")
+	}
+	now := time.Now()
+	m.Products[idx].DeletedAt = &now
+	m.Products[idx].Version++
+	return nil
+}
+
+func (m *MockProductRepository) Stream(_ context.Context, filter domain.ProductFilter, fn func(domain.Product) error) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	for _, p := range m.filtered(filter) {
+		if err := fn(p); err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+func (m *MockProductRepository) Restore(_ context.Context, id int) (domain.Product, error) {
+	if m.Error != nil {
+		return domain.Product{}, m.Error
+	}
+	idx := m.indexBy(func(p domain.Product) bool { return p.ID == id })
 	if idx == -1 {
-		return fmt.Errorf("product not found for deletion")
+		return domain.Product{}, domain.NewNotFoundError("product not found")
 	}
-	m.Products = append(m.Products[:idx], m.Products[idx+1:]...)
-	return nil
+	if m.Products[idx].DeletedAt == nil {
+		return domain.Product{}, domain.NewConflictError("product is not deleted", nil)
+	}
+	if err := m.checkUnique(&m.Products[idx]); err != nil {
+		return domain.Product{}, err
+	}
+	m.Products[idx].DeletedAt = nil
+	m.Products[idx].Version++
+	return m.Products[idx], nil
+}
+
+func (m *MockProductRepository) PurgeDeleted(_ context.Context, deletedBefore time.Time) (int64, error) {
+	if m.Error != nil {
+		return 0, m.Error
+	}
+	var (
+		kept   []domain.Product
+		purged int64
+	)
+	for _, p := range m.Products {
+		if p.DeletedAt != nil && p.DeletedAt.Before(deletedBefore) {
+			purged++
+			continue
+		}
+		kept = append(kept, p)
+	}
+	m.Products = kept
+	return purged, nil
 }
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"e-commerce.com/internal/domain"
+)
+
+// searchQuery parses user input with websearch_to_tsquery, which accepts
+// quoted phrases, "or" and -exclusions and never fails on malformed input.
+const searchQuery = `websearch_to_tsquery('simple', $1)`
+
+// headlineOptions wraps matched words in the domain snippet markers.
+var headlineOptions = fmt.Sprintf(`StartSel="%s", StopSel="%s", MaxWords=30, MinWords=10, MaxFragments=2`,
+	domain.SnippetStart, domain.SnippetStop)
+
+// Search ranks matches with ts_rank_cd over the weighted search_vector, so
+// hits in the name outrank hits in the description.
+func (r *pgProductRepository) Search(ctx context.Context, query string, page, limit int) ([]domain.ProductSearchHit, int, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var total int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM products WHERE deleted_at IS NULL AND search_vector @@ `+searchQuery, query).Scan(&total)
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+	if total == 0 {
+		return []domain.ProductSearchHit{}, 0, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+productColumns+`, ts_rank_cd(search_vector, q) AS rank,
+		       ts_headline('simple', translate(concat_ws('. ', name, NULLIF(description, '')), $2, ''), q, $3)
+		FROM products, `+searchQuery+` AS q
+		WHERE deleted_at IS NULL AND search_vector @@ q
+		ORDER BY rank DESC, id
+		LIMIT $4 OFFSET $5`,
+		query, domain.SnippetStart+domain.SnippetStop, headlineOptions, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, translateError(err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing search rows: %v", err)
+		}
+	}(rows)
+
+	hits := []domain.ProductSearchHit{}
+	for rows.Next() {
+		var (
+			hit     domain.ProductSearchHit
+			snippet string
+		)
+		if err := scanProduct(rows, &hit.Product, &hit.Rank, &snippet); err != nil {
+			return nil, 0, err
+		}
+		hit.Snippet = domain.FormatSnippet(snippet)
+		hits = append(hits, hit)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, translateError(err)
+	}
+	return hits, total, nil
+}
+
+// Suggest matches names starting with prefix and, to tolerate typos, names
+// containing a word similar to it (pg_trgm's <% word-similarity operator).
+func (r *pgProductRepository) Suggest(ctx context.Context, prefix string, limit int) ([]domain.ProductSuggestion, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	prefix = strings.ToLower(prefix)
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, name, slug
+		FROM products
+		WHERE deleted_at IS NULL AND (lower(name) LIKE $1 ESCAPE '\' OR $2 <% lower(name))
+		ORDER BY lower(name) LIKE $1 ESCAPE '\' DESC, word_similarity($2, lower(name)) DESC, length(name), id
+		LIMIT $3`,
+		likeEscaper.Replace(prefix)+"%", prefix, limit)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing suggestion rows: %v", err)
+		}
+	}(rows)
+
+	suggestions := []domain.ProductSuggestion{}
+	for rows.Next() {
+		var s domain.ProductSuggestion
+		if err := rows.Scan(&s.ID, &s.Name, &s.Slug); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"e-commerce.com/internal/domain"
+)
+
+// Search approximates the PostgreSQL search: every query word must appear in
+// the name or description, name matches weigh more, and matched words are
+// marked in the snippet. Query operators are ignored.
+func (m *MockProductRepository) Search(_ context.Context, query string, page, limit int) ([]domain.ProductSearchHit, int, error) {
+	if m.Error != nil {
+		return nil, 0, m.Error
+	}
+	terms := searchWords(query)
+
+	var hits []domain.ProductSearchHit
+	for _, p := range m.Products {
+		if p.DeletedAt != nil {
+			continue
+		}
+		name, description := wordSet(p.Name), wordSet(p.Description)
+		hit := domain.ProductSearchHit{Product: p}
+		for _, term := range terms {
+			if name[term] {
+				hit.Rank += 1
+			} else if description[term] {
+				hit.Rank += 0.4
+			} else {
+				hit.Rank = 0
+				break
+			}
+		}
+		if hit.Rank == 0 {
+			continue
+		}
+		text := p.Name
+		if p.Description != "" {
+			text += ". " + p.Description
+		}
+		hit.Snippet = domain.FormatSnippet(markWords(text, terms))
+		hits = append(hits, hit)
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Rank > hits[j].Rank })
+
+	total := len(hits)
+	start := (page - 1) * limit
+	if start > total {
+		return []domain.ProductSearchHit{}, total, nil
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+	return hits[start:end], total, nil
+}
+
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func wordSet(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range searchWords(text) {
+		set[w] = true
+	}
+	return set
+}
+
+// markWords wraps every occurrence of terms in text with the snippet markers.
+func markWords(text string, terms []string) string {
+	text = strings.NewReplacer(domain.SnippetStart, "", domain.SnippetStop, "").Replace(text)
+	match := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		match[t] = true
+	}
+	var b strings.Builder
+	word := []rune{}
+	flush := func() {
+		if match[strings.ToLower(string(word))] {
+			b.WriteString(domain.SnippetStart + string(word) + domain.SnippetStop)
+		} else {
+			b.WriteString(string(word))
+		}
+		word = word[:0]
+	}
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			word = append(word, r)
+			continue
+		}
+		flush()
+		b.WriteRune(r)
+	}
+	flush()
+	return b.String()
+}
+
+// Suggest approximates the trigram matching with an edit distance: names
+// starting with prefix come first, then names with a word whose start is at
+// most one edit (two for longer prefixes) away from it.
+func (m *MockProductRepository) Suggest(_ context.Context, prefix string, limit int) ([]domain.ProductSuggestion, error) {
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	prefix = strings.ToLower(prefix)
+	tolerance := 1
+	if len([]rune(prefix)) >= 6 {
+		tolerance = 2
+	}
+
+	type candidate struct {
+		product  domain.Product
+		distance int
+	}
+	var candidates []candidate
+	for _, p := range m.Products {
+		if p.DeletedAt != nil {
+			continue
+		}
+		name := strings.ToLower(p.Name)
+		if strings.HasPrefix(name, prefix) {
+			candidates = append(candidates, candidate{p, -1})
+			continue
+		}
+		best := tolerance + 1
+		for _, word := range searchWords(name) {
+			if d := editDistance(prefix, truncateRunes(word, len([]rune(prefix)))); d < best {
+				best = d
+			}
+		}
+		if best <= tolerance {
+			candidates = append(candidates, candidate{p, best})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.distance != b.distance {
+			return a.distance < b.distance
+		}
+		if len(a.product.Name) != len(b.product.Name) {
+			return len(a.product.Name) < len(b.product.Name)
+		}
+		return a.product.ID < b.product.ID
+	})
+
+	suggestions := []domain.ProductSuggestion{}
+	for i := 0; i < len(candidates) && i < limit; i++ {
+		p := candidates[i].product
+		suggestions = append(suggestions, domain.ProductSuggestion{ID: p.ID, Name: p.Name, Slug: p.Slug})
+	}
+	return suggestions, nil
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) > n {
+		runes = runes[:n]
+	}
+	return string(runes)
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
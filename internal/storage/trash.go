@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"e-commerce.com/internal/domain"
+)
+
+func (r *pgProductRepository) Restore(ctx context.Context, id int) (domain.Product, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var restored domain.Product
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		var before domain.Product
+		err := scanProduct(tx.QueryRowContext(ctx, "SELECT "+productColumns+" FROM products WHERE id = $1 FOR UPDATE", id), &before)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.NewNotFoundError("product not found")
+		}
+		if err != nil {
+			return err
+		}
+		if before.DeletedAt == nil {
+			return domain.NewConflictError("product is not deleted", nil)
+		}
+
+		err = scanProduct(tx.QueryRowContext(ctx, `UPDATE products SET deleted_at = NULL, version = version + 1
+			WHERE id = $1 RETURNING `+productColumns, id), &restored)
+		if err != nil {
+			// A live product took the SKU or slug while this one was in the trash.
+			return translateProductError(err)
+		}
+		return recordAudit(ctx, tx, auditChange{entity: domain.AuditEntityProduct, entityID: id, action: domain.AuditRestore, before: before, after: restored})
+	})
+	if err != nil {
+		return domain.Product{}, err
+	}
+	return restored, nil
+}
+
+// PurgeDeleted relies on ON DELETE CASCADE to remove the variants, category
+// assignments and cart items of the purged products. Each purge is audited
+// with the full product, as the last trace of it.
+func (r *pgProductRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var purged int64
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		products, err := queryProducts(ctx, tx, `DELETE FROM products WHERE deleted_at < $1 RETURNING `+productColumns, deletedBefore)
+		if err != nil {
+			return err
+		}
+		for _, p := range products {
+			change := auditChange{entity: domain.AuditEntityProduct, entityID: p.ID, action: domain.AuditPurge, before: p}
+			if err := recordAudit(ctx, tx, change); err != nil {
+				return err
+			}
+		}
+		purged = int64(len(products))
+		return nil
+	})
+	return purged, err
+}
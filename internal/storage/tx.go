@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+)
+
+// inTx runs fn inside a transaction, committing if it succeeds and rolling
+// back otherwise. Errors are passed through translateError.
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return translateError(err)
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return translateError(err)
+	}
+	return translateError(tx.Commit())
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx, so statements can be
+// shared between standalone calls and transactions.
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
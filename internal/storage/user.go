@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"e-commerce.com/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+// pgUserRepository implements the UserRepository interface for PostgreSQL.
+type pgUserRepository struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+// NewUserRepository creates a new instance of the user repository.
+func NewUserRepository(db *sql.DB, queryTimeout time.Duration) domain.UserRepository {
+	return &pgUserRepository{db: db, queryTimeout: queryTimeout}
+}
+
+func (r *pgUserRepository) Save(ctx context.Context, user *domain.User) error {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO users (email, name, password_hash, roles) VALUES ($1, $2, $3, $4) RETURNING id, created_at`,
+		user.Email, user.Name, user.PasswordHash, pq.Array(user.Roles)).Scan(&user.ID, &user.CreatedAt)
+	if isUniqueViolation(err) {
+		return domain.NewConflictError("email is already registered", err)
+	}
+	return translateError(err)
+}
+
+func (r *pgUserRepository) FindByID(ctx context.Context, id int) (domain.User, error) {
+	return r.findOne(ctx, "id = $1", id)
+}
+
+func (r *pgUserRepository) FindByEmail(ctx context.Context, email string) (domain.User, error) {
+	return r.findOne(ctx, "email = $1", domain.NormalizeEmail(email))
+}
+
+func (r *pgUserRepository) findOne(ctx context.Context, condition string, arg interface{}) (domain.User, error) {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var u domain.User
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, email, name, password_hash, roles, created_at FROM users WHERE "+condition, arg).
+		Scan(&u.ID, &u.Email, &u.Name, &u.PasswordHash, pq.Array(&u.Roles), &u.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.User{}, domain.NewNotFoundError("user not found")
+		}
+		return domain.User{}, translateError(err)
+	}
+	return u, nil
+}
+
+// pgRefreshTokenRepository implements the RefreshTokenRepository interface for PostgreSQL.
+type pgRefreshTokenRepository struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+// NewRefreshTokenRepository creates a new instance of the refresh token repository.
+func NewRefreshTokenRepository(db *sql.DB, queryTimeout time.Duration) domain.RefreshTokenRepository {
+	return &pgRefreshTokenRepository{db: db, queryTimeout: queryTimeout}
+}
+
+func (r *pgRefreshTokenRepository) Save(ctx context.Context, token *domain.RefreshToken) error {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO refresh_tokens (id, user_id, family_id, expires_at) VALUES ($1, $2, $3, $4) RETURNING created_at`,
+		token.ID, token.UserID, token.FamilyID, token.ExpiresAt).Scan(&token.CreatedAt)
+	return translateError(err)
+}
+
+func (r *pgRefreshTokenRepository) FindByID(ctx context.Context, id string) (domain.RefreshToken, error) {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	var t domain.RefreshToken
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, user_id, family_id, expires_at, revoked_at, created_at FROM refresh_tokens WHERE id = $1`, id).
+		Scan(&t.ID, &t.UserID, &t.FamilyID, &t.ExpiresAt, &t.RevokedAt, &t.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.RefreshToken{}, domain.NewNotFoundError("refresh token not found")
+		}
+		return domain.RefreshToken{}, translateError(err)
+	}
+	return t, nil
+}
+
+// Revoke only updates tokens that are still active, so two concurrent
+// refreshes with the same token cannot both succeed.
+func (r *pgRefreshTokenRepository) Revoke(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE refresh_tokens SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return translateError(err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return domain.NewConflictError("refresh token has already been used", nil)
+	}
+	return nil
+}
+
+func (r *pgRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE refresh_tokens SET revoked_at = now() WHERE family_id = $1 AND revoked_at IS NULL`, familyID)
+	return translateError(err)
+}
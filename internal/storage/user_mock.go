@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"e-commerce.com/internal/domain"
+)
+
+// MockUserRepository is an in-memory UserRepository.
+type MockUserRepository struct {
+	Users []domain.User
+	Error error
+}
+
+func (m *MockUserRepository) Save(_ context.Context, user *domain.User) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	for _, u := range m.Users {
+		if u.Email == user.Email {
+			return domain.NewConflictError("email is already registered", nil)
+		}
+	}
+	user.ID = len(m.Users) + 1
+	user.CreatedAt = time.Now()
+	m.Users = append(m.Users, *user)
+	return nil
+}
+
+func (m *MockUserRepository) FindByID(_ context.Context, id int) (domain.User, error) {
+	if m.Error != nil {
+		return domain.User{}, m.Error
+	}
+	for _, u := range m.Users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return domain.User{}, domain.NewNotFoundError("user not found")
+}
+
+func (m *MockUserRepository) FindByEmail(_ context.Context, email string) (domain.User, error) {
+	if m.Error != nil {
+		return domain.User{}, m.Error
+	}
+	email = domain.NormalizeEmail(email)
+	for _, u := range m.Users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return domain.User{}, domain.NewNotFoundError("user not found")
+}
+
+// MockRefreshTokenRepository is an in-memory RefreshTokenRepository.
+type MockRefreshTokenRepository struct {
+	Tokens []domain.RefreshToken
+	Error  error
+}
+
+func (m *MockRefreshTokenRepository) Save(_ context.Context, token *domain.RefreshToken) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	token.CreatedAt = time.Now()
+	m.Tokens = append(m.Tokens, *token)
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) FindByID(_ context.Context, id string) (domain.RefreshToken, error) {
+	if m.Error != nil {
+		return domain.RefreshToken{}, m.Error
+	}
+	for _, t := range m.Tokens {
+		if t.ID == id {
+			return t, nil
+		}
+	}
+	return domain.RefreshToken{}, domain.NewNotFoundError("refresh token not found")
+}
+
+func (m *MockRefreshTokenRepository) Revoke(_ context.Context, id string) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	for i := range m.Tokens {
+		if m.Tokens[i].ID == id {
+			if m.Tokens[i].RevokedAt != nil {
+				return domain.NewConflictError("refresh token has already been used", nil)
+			}
+			now := time.Now()
+			m.Tokens[i].RevokedAt = &now
+			return nil
+		}
+	}
+	return domain.NewNotFoundError("refresh token not found")
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(_ context.Context, familyID string) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	now := time.Now()
+	for i := range m.Tokens {
+		if m.Tokens[i].FamilyID == familyID && m.Tokens[i].RevokedAt == nil {
+			m.Tokens[i].RevokedAt = &now
+		}
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"e-commerce.com/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+// pgVariantRepository implements the VariantRepository interface for PostgreSQL.
+type pgVariantRepository struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+// NewVariantRepository creates a new instance of the variant repository.
+func NewVariantRepository(db *sql.DB, queryTimeout time.Duration) domain.VariantRepository {
+	return &pgVariantRepository{db: db, queryTimeout: queryTimeout}
+}
+
+// variantColumns selects a variant together with its product's currency,
+// which the price override is denominated in.
+const variantColumns = "v.id, v.product_id, v.sku, v.options, p.currency, v.price, v.amount"
+
+const variantFrom = " FROM product_variants v JOIN products p ON p.id = v.product_id"
+
+func scanVariant(row interface{ Scan(...interface{}) error }) (domain.Variant, error) {
+	var (
+		v        domain.Variant
+		options  []byte
+		currency string
+		price    sql.NullString
+	)
+	if err := row.Scan(&v.ID, &v.ProductID, &v.SKU, &options, &currency, &price, &v.Amount); err != nil {
+		return v, err
+	}
+	if err := json.Unmarshal(options, &v.Options); err != nil {
+		return v, err
+	}
+	if price.Valid {
+		m := domain.Money{Currency: currency}
+		if err := m.Scan(price.String); err != nil {
+			return v, err
+		}
+		v.Price = &m
+	}
+	return v, nil
+}
+
+// variantArgs converts the columns that need encoding.
+func variantArgs(v *domain.Variant) ([]byte, interface{}, error) {
+	options := v.Options
+	if options == nil {
+		options = map[string]string{}
+	}
+	encoded, err := json.Marshal(options)
+	if err != nil {
+		return nil, nil, err
+	}
+	var price interface{}
+	if v.Price != nil {
+		price = *v.Price
+	}
+	return encoded, price, nil
+}
+
+// translateVariantError reports duplicate SKUs as conflicts with a useful message.
+func translateVariantError(err error) error {
+	if isUniqueViolation(err) {
+		return domain.NewConflictError("sku already exists", err)
+	}
+	if isForeignKeyViolation(err) {
+		return domain.NewNotFoundError("product not found")
+	}
+	return translateError(err)
+}
+
+func (r *pgVariantRepository) Save(ctx context.Context, variant *domain.Variant) error {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	options, price, err := variantArgs(variant)
+	if err != nil {
+		return err
+	}
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := lockVariantProduct(ctx, tx, variant.ProductID, variant.Price); err != nil {
+			return err
+		}
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO product_variants (product_id, sku, options, price, amount) VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+			variant.ProductID, variant.SKU, options, price, variant.Amount).Scan(&variant.ID)
+		if err != nil {
+			return translateVariantError(err)
+		}
+		if err := bumpProductVersion(ctx, tx, variant.ProductID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{entity: domain.AuditEntityVariant, entityID: variant.ID, action: domain.AuditCreate, after: *variant})
+	})
+}
+
+// lockVariant reads a variant about to be changed and locks its row until tx ends.
+func lockVariant(ctx context.Context, tx *sql.Tx, productID, id int) (domain.Variant, error) {
+	v, err := scanVariant(tx.QueryRowContext(ctx,
+		"SELECT "+variantColumns+variantFrom+" WHERE v.product_id = $1 AND v.id = $2 FOR UPDATE OF v", productID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Variant{}, domain.NewNotFoundError("variant not found")
+	}
+	return v, err
+}
+
+// lockVariantProduct locks the parent product until tx ends, so its currency
+// cannot change while a variant is written, and checks that price, when set,
+// is in that currency. Variant writes lock the product before the variant,
+// the same order checkouts lock them in.
+func lockVariantProduct(ctx context.Context, tx *sql.Tx, productID int, price *domain.Money) error {
+	var currency string
+	err := tx.QueryRowContext(ctx, `SELECT currency FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, productID).Scan(&currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.NewNotFoundError("product not found")
+	}
+	if err != nil {
+		return err
+	}
+	if price != nil && price.Currency != currency {
+		return domain.NewValidationError("Invalid variant data: price must be in the product's currency")
+	}
+	return nil
+}
+
+// bumpProductVersion marks the parent product as changed, since its
+// representation includes the variant summary.
+func bumpProductVersion(ctx context.Context, tx *sql.Tx, productID int) error {
+	_, err := tx.ExecContext(ctx, `UPDATE products SET version = version + 1 WHERE id = $1`, productID)
+	return err
+}
+
+func (r *pgVariantRepository) FindByProduct(ctx context.Context, productID int) ([]domain.Variant, error) {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+variantColumns+variantFrom+" WHERE v.product_id = $1 ORDER BY v.id", productID)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing variant rows: %v", err)
+		}
+	}(rows)
+
+	var variants []domain.Variant
+	for rows.Next() {
+		v, err := scanVariant(rows)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	return variants, nil
+}
+
+func (r *pgVariantRepository) FindByID(ctx context.Context, productID, id int) (domain.Variant, error) {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	v, err := scanVariant(r.db.QueryRowContext(ctx,
+		"SELECT "+variantColumns+variantFrom+" WHERE v.product_id = $1 AND v.id = $2", productID, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Variant{}, domain.NewNotFoundError("variant not found")
+		}
+		return domain.Variant{}, translateError(err)
+	}
+	return v, nil
+}
+
+func (r *pgVariantRepository) Update(ctx context.Context, variant *domain.Variant) error {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	options, price, err := variantArgs(variant)
+	if err != nil {
+		return err
+	}
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := lockVariantProduct(ctx, tx, variant.ProductID, variant.Price); err != nil {
+			return err
+		}
+		before, err := lockVariant(ctx, tx, variant.ProductID, variant.ID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			`UPDATE product_variants SET sku = $1, options = $2, price = $3, amount = $4 WHERE product_id = $5 AND id = $6`,
+			variant.SKU, options, price, variant.Amount, variant.ProductID, variant.ID)
+		if err != nil {
+			return translateVariantError(err)
+		}
+		if err := bumpProductVersion(ctx, tx, variant.ProductID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{entity: domain.AuditEntityVariant, entityID: variant.ID, action: domain.AuditUpdate, before: before, after: *variant})
+	})
+}
+
+func (r *pgVariantRepository) Delete(ctx context.Context, productID, id int) error {
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := lockVariantProduct(ctx, tx, productID, nil); err != nil {
+			return err
+		}
+		before, err := lockVariant(ctx, tx, productID, id)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM product_variants WHERE id = $1`, id); err != nil {
+			return err
+		}
+		if err := bumpProductVersion(ctx, tx, productID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, auditChange{entity: domain.AuditEntityVariant, entityID: id, action: domain.AuditDelete, before: before})
+	})
+}
+
+// Summaries aggregates in the database, so a listing costs one extra query
+// however many variants its products have.
+func (r *pgVariantRepository) Summaries(ctx context.Context, productIDs []int) (map[int]domain.VariantSummary, error) {
+	summaries := make(map[int]domain.VariantSummary)
+	if len(productIDs) == 0 {
+		return summaries, nil
+	}
+	ctx, cancel := withTimeout(ctx, r.queryTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT v.product_id, COUNT(*), SUM(v.amount), p.currency,
+		        MIN(COALESCE(v.price, p.price)), MAX(COALESCE(v.price, p.price))`+variantFrom+`
+		 WHERE v.product_id = ANY($1)
+		 GROUP BY v.product_id, p.currency`, pq.Array(productIDs))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Printf("Error closing variant summary rows: %v", err)
+		}
+	}(rows)
+
+	for rows.Next() {
+		var (
+			productID int
+			s         domain.VariantSummary
+			currency  string
+			min, max  string
+		)
+		if err := rows.Scan(&productID, &s.Count, &s.TotalStock, &currency, &min, &max); err != nil {
+			return nil, err
+		}
+		// Money.Scan scales by the currency, so it has to be known first.
+		s.PriceRange.Min.Currency, s.PriceRange.Max.Currency = currency, currency
+		if err := s.PriceRange.Min.Scan(min); err != nil {
+			return nil, err
+		}
+		if err := s.PriceRange.Max.Scan(max); err != nil {
+			return nil, err
+		}
+		summaries[productID] = s
+	}
+	if err = rows.Err(); err != nil {
+		return nil, translateError(err)
+	}
+	return summaries, nil
+}
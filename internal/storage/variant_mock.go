@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"context"
+
+	"e-commerce.com/internal/domain"
+)
+
+// MockVariantRepository is an in-memory VariantRepository. Products supplies
+// the product prices Summaries falls back to and must be set once variants
+// exist.
+type MockVariantRepository struct {
+	Variants []domain.Variant
+	Products *MockProductRepository
+	Error    error
+}
+
+func (m *MockVariantRepository) Save(_ context.Context, variant *domain.Variant) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	if m.skuTaken(variant.SKU, 0) {
+		return domain.NewConflictError("sku already exists", nil)
+	}
+	variant.ID = len(m.Variants) + 1
+	m.Variants = append(m.Variants, *variant)
+	m.bumpProductVersion(variant.ProductID)
+	return nil
+}
+
+func (m *MockVariantRepository) FindByProduct(_ context.Context, productID int) ([]domain.Variant, error) {
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	var variants []domain.Variant
+	for _, v := range m.Variants {
+		if v.ProductID == productID {
+			variants = append(variants, v)
+		}
+	}
+	return variants, nil
+}
+
+func (m *MockVariantRepository) FindByID(_ context.Context, productID, id int) (domain.Variant, error) {
+	if m.Error != nil {
+		return domain.Variant{}, m.Error
+	}
+	idx := m.indexOf(productID, id)
+	if idx == -1 {
+		return domain.Variant{}, domain.NewNotFoundError("variant not found")
+	}
+	return m.Variants[idx], nil
+}
+
+func (m *MockVariantRepository) Update(_ context.Context, variant *domain.Variant) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	idx := m.indexOf(variant.ProductID, variant.ID)
+	if idx == -1 {
+		return domain.NewNotFoundError("variant not found")
+	}
+	if m.skuTaken(variant.SKU, variant.ID) {
+		return domain.NewConflictError("sku already exists", nil)
+	}
+	m.Variants[idx] = *variant
+	m.bumpProductVersion(variant.ProductID)
+	return nil
+}
+
+func (m *MockVariantRepository) Delete(_ context.Context, productID, id int) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	idx := m.indexOf(productID, id)
+	if idx == -1 {
+		return domain.NewNotFoundError("variant not found")
+	}
+	m.Variants = append(m.Variants[:idx], m.Variants[idx+1:]...)
+	m.bumpProductVersion(productID)
+	return nil
+}
+
+func (m *MockVariantRepository) Summaries(ctx context.Context, productIDs []int) (map[int]domain.VariantSummary, error) {
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	summaries := make(map[int]domain.VariantSummary)
+	for _, id := range productIDs {
+		variants, _ := m.FindByProduct(ctx, id)
+		if len(variants) == 0 {
+			continue
+		}
+		product, err := m.Products.FindByID(ctx, id)
+		if err != nil {
+			continue
+		}
+		if summary := domain.SummarizeVariants(product, variants); summary != nil {
+			summaries[id] = *summary
+		}
+	}
+	return summaries, nil
+}
+
+// bumpProductVersion mirrors the version bump of the parent product.
+func (m *MockVariantRepository) bumpProductVersion(productID int) {
+	if m.Products == nil {
+		return
+	}
+	if idx := m.Products.indexBy(func(p domain.Product) bool { return p.ID == productID }); idx != -1 {
+		m.Products.Products[idx].Version++
+	}
+}
+
+func (m *MockVariantRepository) indexOf(productID, id int) int {
+	for i, v := range m.Variants {
+		if v.ProductID == productID && v.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// skuTaken reports whether a variant other than exceptID uses sku.
+func (m *MockVariantRepository) skuTaken(sku string, exceptID int) bool {
+	for _, v := range m.Variants {
+		if v.SKU == sku && v.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// runEvery calls job every interval until ctx is cancelled. Failures are
+// logged and retried on the next tick.
+func runEvery(ctx context.Context, interval time.Duration, name string, job func(context.Context) (int64, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := job(ctx)
+			if err != nil {
+				log.Printf("%s: %v", name, err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("%s: %d removed", name, n)
+			}
+		}
+	}
+}
@@ -17,13 +17,17 @@ package main
 // @BasePath  /
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
+	"e-commerce.com/internal/auth"
 	productHandler "e-commerce.com/internal/handler/http"
+	"e-commerce.com/internal/payment"
 	"e-commerce.com/internal/storage"
 
 	"github.com/go-chi/chi/v5"
@@ -34,28 +38,141 @@ import (
 )
 
 // setupRouter creates and configures the chi router with all dependencies and routes.
-func setupRouter(db *sql.DB) *chi.Mux {
-	productRepo := storage.NewProductRepository(db)
-	productH := productHandler.NewProductHandler(productRepo)
+func setupRouter(db *sql.DB, cfg config) *chi.Mux {
+	productRepo := storage.NewProductRepository(db, cfg.QueryTimeout)
+	categoryRepo := storage.NewCategoryRepository(db, cfg.QueryTimeout)
+	variantRepo := storage.NewVariantRepository(db, cfg.QueryTimeout)
+	productH := productHandler.NewProductHandler(productRepo, categoryRepo, variantRepo)
+	variantH := productHandler.NewVariantHandler(variantRepo, productRepo)
+	categoryH := productHandler.NewCategoryHandler(categoryRepo)
+	auditH := productHandler.NewAuditHandler(storage.NewAuditRepository(db, cfg.QueryTimeout))
+
+	verifier := auth.NewVerifier(cfg.Auth)
+	if !verifier.Enabled() {
+		log.Println("Warning: no JWT key configured; write endpoints will reject every request.")
+	}
+	issuer := auth.NewIssuer(cfg.Tokens)
+	authH := productHandler.NewAuthHandler(
+		storage.NewUserRepository(db, cfg.QueryTimeout),
+		storage.NewRefreshTokenRepository(db, cfg.QueryTimeout),
+		issuer, verifier)
+	cartRepo := storage.NewCartRepository(db, cfg.QueryTimeout)
+	cartH := productHandler.NewCartHandler(cartRepo, productRepo)
+	orderRepo := storage.NewOrderRepository(db, cfg.QueryTimeout)
+	paymentRepo := storage.NewPaymentRepository(db, cfg.QueryTimeout)
+	// The fake provider is the only one so far; loadConfig rejects any other.
+	provider := payment.NewFakeProvider()
+	orderH := productHandler.NewOrderHandler(orderRepo, cartRepo, paymentRepo, provider)
+	paymentH := productHandler.NewPaymentHandler(paymentRepo, orderRepo, provider, cfg.PaymentWebhookSecret)
+	// POSTs with an Idempotency-Key header can be retried safely. It runs
+	// after authentication because keys are scoped to the caller.
+	idempotent := productHandler.Idempotency(storage.NewIdempotencyRepository(db, cfg.QueryTimeout), cfg.IdempotencyKeyTTL)
+	// Reads are public; catalog changes require an admin or catalog-manager token.
+	catalogManager := []func(http.Handler) http.Handler{
+		productHandler.Authenticate(verifier),
+		productHandler.RequireRole(auth.RoleAdmin, auth.RoleCatalogManager),
+	}
+	catalogWriter := append(catalogManager, idempotent)
 
 	r := chi.NewRouter()
+	// The request ID is logged and recorded with every audited change.
+	r.Use(middleware.RequestID)
 	r.Use(middleware.Logger)
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins: []string{"http://localhost:3000", "http://localhost:5173"},
-		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "If-Match", "If-None-Match", "Idempotency-Key"},
+		ExposedHeaders: []string{"ETag", "Idempotent-Replayed"},
 	}))
 
+	r.Route("/auth", func(r chi.Router) {
+		r.Post("/register", authH.Register)
+		r.Post("/login", authH.Login)
+		r.Post("/refresh", authH.Refresh)
+		r.Post("/logout", authH.Logout)
+	})
+	r.With(productHandler.Authenticate(verifier)).Get("/me", authH.Me)
+
 	r.Route("/products", func(r chi.Router) {
 		r.Get("/", productH.ListProducts)
-		r.Post("/", productH.CreateProduct)
+		r.With(catalogWriter...).Post("/", productH.CreateProduct)
+		r.With(catalogWriter...).Post("/bulk", productH.BulkProducts)
+		// Imports stream their upload, which the idempotency check would buffer.
+		r.With(catalogManager...).Post("/import", productH.ImportProducts)
+		r.Get("/export", productH.ExportProducts)
+		r.With(catalogManager...).Get("/trash", productH.ListTrash)
+		r.Get("/search", productH.SearchProducts)
+		r.Get("/suggest", productH.SuggestProducts)
+		r.Get("/by-sku/{sku}", productH.GetProductBySKU)
+		r.Get("/by-slug/{slug}", productH.GetProductBySlug)
 		r.Route("/{id}", func(r chi.Router) {
 			r.Get("/", productH.GetProduct)
-			r.Put("/", productH.UpdateProduct)
-			r.Delete("/", productH.DeleteProduct)
+			r.With(catalogWriter...).Put("/", productH.UpdateProduct)
+			r.With(catalogWriter...).Patch("/", productH.PatchProduct)
+			r.With(catalogWriter...).Delete("/", productH.DeleteProduct)
+			r.With(catalogWriter...).Post("/restore", productH.RestoreProduct)
+			r.Route("/variants", func(r chi.Router) {
+				r.Get("/", variantH.ListVariants)
+				r.With(catalogWriter...).Post("/", variantH.CreateVariant)
+				r.Get("/{variantID}", variantH.GetVariant)
+				r.With(catalogWriter...).Put("/{variantID}", variantH.UpdateVariant)
+				r.With(catalogWriter...).Delete("/{variantID}", variantH.DeleteVariant)
+			})
+		})
+	})
+
+	// Carts can be used anonymously; a bearer token ties the cart to its user.
+	// Only authenticated requests may send an Idempotency-Key.
+	r.Route("/carts", func(r chi.Router) {
+		r.Use(productHandler.OptionalAuthenticate(verifier), idempotent)
+		r.Post("/", cartH.CreateCart)
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", cartH.GetCart)
+			r.Delete("/", cartH.DeleteCart)
+			r.Post("/items", cartH.AddItem)
+			r.Put("/items/{productID}", cartH.UpdateItem)
+			r.Delete("/items/{productID}", cartH.RemoveItem)
+		})
+	})
+
+	r.Route("/orders", func(r chi.Router) {
+		r.Use(productHandler.Authenticate(verifier), idempotent)
+		r.Post("/", orderH.PlaceOrder)
+		r.Get("/", orderH.ListOrders)
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", orderH.GetOrder)
+			r.Post("/transitions", orderH.TransitionOrder)
+			r.Get("/history", orderH.OrderHistory)
+			r.Post("/payments", paymentH.AuthorizePayment)
+			r.Get("/payments", paymentH.ListOrderPayments)
+		})
+	})
+
+	r.Route("/payments", func(r chi.Router) {
+		// Webhooks authenticate with their signature instead of a bearer token.
+		r.Post("/webhook", paymentH.Webhook)
+		r.Group(func(r chi.Router) {
+			r.Use(productHandler.Authenticate(verifier), productHandler.RequireRole(auth.RoleAdmin))
+			r.Post("/{id}/capture", paymentH.CapturePayment)
+			r.Post("/{id}/void", paymentH.VoidPayment)
+			r.Post("/{id}/refund", paymentH.RefundPayment)
 		})
 	})
 
+	r.Route("/categories", func(r chi.Router) {
+		r.Get("/", categoryH.ListCategories)
+		r.With(catalogWriter...).Post("/", categoryH.CreateCategory)
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", categoryH.GetCategory)
+			r.With(catalogWriter...).Put("/", categoryH.UpdateCategory)
+			r.With(catalogWriter...).Delete("/", categoryH.DeleteCategory)
+			r.With(catalogWriter...).Put("/products/{productID}", categoryH.AssignProduct)
+			r.With(catalogWriter...).Delete("/products/{productID}", categoryH.UnassignProduct)
+		})
+	})
+
+	r.With(catalogManager...).Get("/audit", auditH.ListAudit)
+
 	return r
 }
 
@@ -63,6 +180,10 @@ func main() {
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: Could not load .env file.")
 	}
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading configuration: %v", err)
+	}
 
 	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_USER"),
@@ -83,17 +204,36 @@ func main() {
 		log.Fatalf("Error connecting to the database: %v", err)
 	}
 
-	createTableSQL := `CREATE TABLE IF NOT EXISTS products (
-		id SERIAL PRIMARY KEY, name TEXT NOT NULL, price NUMERIC(10, 2) NOT NULL,
-		amount INTEGER NOT NULL, description TEXT
-	);`
-	if _, err = db.Exec(createTableSQL); err != nil {
-		log.Fatalf("Error creating table: %v", err)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(context.Background(), db, os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
 	}
-	log.Println("Database connected and table ready.")
+
+	if err = runMigrations(context.Background(), db); err != nil {
+		log.Fatalf("Error running migrations: %v", err)
+	}
+	log.Println("Database connected and schema up to date.")
+
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImportCommand(context.Background(), db, cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Import failed: %v", err)
+		}
+		return
+	}
+
+	jobs, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go runEvery(jobs, time.Hour, "purge expired idempotency keys",
+		storage.NewIdempotencyRepository(db, cfg.QueryTimeout).DeleteExpired)
+	products := storage.NewProductRepository(db, cfg.QueryTimeout)
+	go runEvery(jobs, time.Hour, "purge deleted products", func(ctx context.Context) (int64, error) {
+		return products.PurgeDeleted(ctx, time.Now().Add(-cfg.TrashRetention))
+	})
 
 	// Just call setupRouter and start the server.
-	router := setupRouter(db)
+	router := setupRouter(db, cfg)
 	log.Println("Server starting on port :8080")
 	if err := http.ListenAndServe(":8080", router); err != nil {
 		log.Fatalf("Server failed to start: %v", err)